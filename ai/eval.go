@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/imega/snake-game/snake"
+	"github.com/imega/snake-game/state"
+	"github.com/nsf/termbox-go"
+)
+
+// Evaluation is the outcome of headless games played by a brain
+type Evaluation struct {
+	Scores  []int
+	Best    int
+	Mean    float64
+	Fitness float64
+	Steps   int
+}
+
+// Evaluate plays games without a terminal with the brain from the file,
+// each game has its own seed derived from p.Seed and ends when the snake
+// dies, starves after p.MaxSnakeSteps or runs out of p.MaxTicks, the games
+// are recorded into the p.Record directory when it is set
+func Evaluate(p state.Parameters, games int) (Evaluation, error) {
+	if mayNeverEnd(p) {
+		return Evaluation{}, fmt.Errorf("games without max steps or max ticks may never end")
+	}
+
+	brain, err := loadBrain(p)
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("failed to load brain, %s", err)
+	}
+
+	e, err := snake.NewEngine(p)
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("failed to create engine, %s", err)
+	}
+
+	var ev Evaluation
+
+	for i := 0; i < games; i++ {
+		st := e.Reset(p.Seed + int64(i))
+
+		for !st.IsOver {
+			st, _, _ = e.Step(keyToAction(brain.Neuronet.decide(st)))
+			ev.Steps++
+		}
+
+		ev.Scores = append(ev.Scores, st.Score)
+
+		if p.Record != "" {
+			if _, err := e.Replay().Save(p.Record); err != nil {
+				return Evaluation{}, err
+			}
+		}
+
+		if st.Score > ev.Best {
+			ev.Best = st.Score
+		}
+
+		ev.Mean += float64(st.Score) / float64(games)
+		ev.Fitness += float64(st.Fitness) / float64(games)
+	}
+
+	return ev, nil
+}
+
+// mayNeverEnd tells whether a brain may play a game forever, circling the
+// arena without eating, when nothing limits its length
+func mayNeverEnd(p state.Parameters) bool {
+	return p.MaxSnakeSteps == 0 && p.MaxTicks == 0 && p.Mode != snake.ModeTimeAttack
+}
+
+func keyToAction(k termbox.Key) snake.Action {
+	switch k {
+	case termbox.KeyArrowRight:
+		return snake.MoveRight
+	case termbox.KeyArrowLeft:
+		return snake.MoveLeft
+	case termbox.KeyArrowUp:
+		return snake.MoveUp
+	case termbox.KeyArrowDown:
+		return snake.MoveDown
+	default:
+		return snake.NOOP
+	}
+}
+
+// BrainInfo describes a brain file
+type BrainInfo struct {
+	Score   int
+	Layers  []int
+	Weights int
+	Min     float64
+	Max     float64
+	Mean    float64
+}
+
+// Inspect reads the brain from the file and summarises its weights
+func Inspect(p state.Parameters) (BrainInfo, error) {
+	brain, err := loadBrain(p)
+	if err != nil {
+		return BrainInfo{}, fmt.Errorf("failed to load brain, %s", err)
+	}
+
+	n := brain.Neuronet
+	info := BrainInfo{
+		Score: brain.Score,
+		Layers: []int{
+			len(n.WeightHidden1),
+			len(n.BiasHidden1),
+			len(n.BiasHidden2),
+			len(n.BiasOut),
+		},
+		Min: math.Inf(1),
+		Max: math.Inf(-1),
+	}
+
+	for _, w := range n.weights() {
+		info.Weights++
+		info.Min = math.Min(info.Min, w)
+		info.Max = math.Max(info.Max, w)
+		info.Mean += w
+	}
+
+	info.Mean /= float64(info.Weights)
+
+	return info, nil
+}
+
+// weights returns every weight and bias of the network
+func (n *neuronet) weights() []float64 {
+	var w []float64
+
+	for i := range n.WeightHidden1 {
+		w = append(w, n.WeightHidden1[i][:]...)
+	}
+
+	w = append(w, n.BiasHidden1[:]...)
+
+	for i := range n.WeightHidden2 {
+		w = append(w, n.WeightHidden2[i][:]...)
+	}
+
+	w = append(w, n.BiasHidden2[:]...)
+
+	for i := range n.WeightOutput {
+		w = append(w, n.WeightOutput[i][:]...)
+	}
+
+	return append(w, n.BiasOut[:]...)
+}
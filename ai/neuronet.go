@@ -9,7 +9,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/imega/snake-game/snake"
 	"github.com/imega/snake-game/state"
@@ -19,135 +18,192 @@ import (
 type Result struct {
 	Neuronet neuronet
 	Score    int
+	// Fitness ranks the brains of an epoch when scoring rules are turned
+	// on, it counts every rule
+	Fitness int
 }
 
-func New(p state.Parameters, ch chan state.SnakeGame, pad chan snake.KeyboardEvent, statCh chan state.Stat) error {
-	var (
-		population    []Result
-		instance      int
-		epoch         int
-		maxEpochScore int
-		bestBrain     Result
+// New trains the brain from the file on the engine. The games are played
+// in lockstep with the brain, so the same seed gives the same brains, and
+// show draws every tick unless it is nil.
+func New(p state.Parameters, e *snake.Engine, show func(state.Stat) error) error {
+	if mayNeverEnd(p) {
+		return fmt.Errorf("games without max steps or max ticks may never end")
+	}
 
-		maxInstance = p.MaxInstance
+	t, err := newTrainer(p)
+	if err != nil {
+		return err
+	}
 
-		n = neuronet{}
+	e.Reset(p.Seed)
 
-		lastState state.SnakeGame
-		lastKey   termbox.Key
-	)
+	for {
+		if err := t.game(e, show); err != nil {
+			return err
+		}
+	}
+}
+
+// trainer plays every game of an epoch with a mutation of the best brain
+// and crosses the best brain of the epoch into it
+type trainer struct {
+	p             state.Parameters
+	rnd           *rand.Rand
+	n             neuronet
+	population    []Result
+	instance      int
+	epoch         int
+	maxEpochScore int
+	bestBrain     Result
+}
 
+func newTrainer(p state.Parameters) (*trainer, error) {
 	brain, err := loadBrain(p)
 	if err != nil {
-		return fmt.Errorf("failed to load brain, %s", err)
+		return nil, fmt.Errorf("failed to load brain, %s", err)
 	}
-	bestBrain = brain
 
-	for st := range ch {
-		var key termbox.Key
+	return &trainer{
+		p:         p,
+		rnd:       rand.New(rand.NewSource(p.Seed)),
+		bestBrain: brain,
+	}, nil
+}
 
-		if st.Snake.Steps > p.MaxSnakeSteps {
-			pad <- snake.KeyboardEvent{EventType: snake.RETRY}
+// game plays the current game of the engine to its end, learns from it
+// and starts the next one
+func (t *trainer) game(e *snake.Engine, show func(state.Stat) error) error {
+	st := e.State()
 
-			instance++
+	for !st.IsOver {
+		st, _, _ = e.Step(keyToAction(t.n.decide(st)))
 
-			continue
+		if t.maxEpochScore < st.Score {
+			t.maxEpochScore = st.Score
+			if t.p.Silent {
+				fmt.Printf(
+					"Snake Game MaxScore: %d, epoch: %d, epochMaxScore: %d, inst: %d\n",
+					t.bestBrain.Score,
+					t.epoch,
+					t.maxEpochScore,
+					t.instance,
+				)
+			}
 		}
 
-		if st.IsOver {
-			if st.Score > p.MinScoreEpoch {
-				population = append(population, Result{
-					Neuronet: n,
-					Score:    st.Score,
-				})
+		if show != nil {
+			if err := show(t.stat()); err != nil {
+				return err
 			}
-			if instance < maxInstance {
-				pad <- snake.KeyboardEvent{EventType: snake.RETRY}
-				n = mutate(bestBrain.Neuronet, p.MutationRate, p.MutationRange)
+		}
+	}
 
-				instance++
-			}
+	if t.p.Record != "" {
+		if _, err := e.Replay().Save(t.p.Record); err != nil {
+			return err
+		}
+	}
 
-			if instance >= maxInstance {
-				epoch++
-
-				var best Result
-				for _, p := range population {
-					if best.Score < p.Score {
-						best = p
-					}
-				}
-
-				population = nil
-				instance = 0
-				maxEpochScore = 0
-
-				if best.Score > 0 {
-					bestBrain.Neuronet = crossoverBrain(bestBrain.Neuronet, best.Neuronet)
-				}
-
-				if bestBrain.Score < best.Score {
-					bestBrain.Score = best.Score
-					if err := saveBrain(p, bestBrain); err != nil {
-						return fmt.Errorf("failed to save brain, %w", err)
-					}
-				}
-			}
+	if err := t.learn(st); err != nil {
+		return err
+	}
 
-			if !p.Silent {
-				statCh <- state.Stat{
-					Epoch:         epoch,
-					Instance:      instance,
-					BestScore:     bestBrain.Score,
-					MaxEpochScore: maxEpochScore,
-				}
-			}
+	e.Retry()
 
-			continue
+	return nil
+}
+
+// learn keeps the finished game in the population, mutates the brain for
+// the next game and ends the epoch after the last instance
+func (t *trainer) learn(st state.SnakeGame) error {
+	if st.Score > t.p.MinScoreEpoch {
+		t.population = append(t.population, Result{
+			Neuronet: t.n,
+			Score:    st.Score,
+			Fitness:  st.Fitness,
+		})
+	}
+
+	t.n = mutate(t.rnd, t.bestBrain.Neuronet, t.p.MutationRate, t.p.MutationRange)
+	t.instance++
+
+	if t.instance < t.p.MaxInstance {
+		return nil
+	}
+
+	t.epoch++
+
+	best := t.fittest()
+
+	t.population = nil
+	t.instance = 0
+	t.maxEpochScore = 0
+
+	if best.Score > 0 {
+		t.bestBrain.Neuronet = crossoverBrain(t.rnd, t.bestBrain.Neuronet, best.Neuronet)
+	}
+
+	if t.bestBrain.Score < best.Score {
+		t.bestBrain.Score = best.Score
+		if err := saveBrain(t.p, t.bestBrain); err != nil {
+			return fmt.Errorf("failed to save brain, %w", err)
 		}
+	}
 
-		if maxEpochScore < st.Score {
-			maxEpochScore = st.Score
-			if p.Silent {
-				fmt.Printf(
-					"Snake Game MaxScore: %d, epoch: %d, epochMaxScore: %d, inst: %d\n",
-					bestBrain.Score,
-					epoch,
-					maxEpochScore,
-					instance,
-				)
-			}
+	return nil
+}
+
+// fittest is the brain of the epoch to cross in: one that beat the best
+// score, else the best one by fitness when scoring rules are turned on
+// and by score when they are not
+func (t *trainer) fittest() Result {
+	var best, top Result
+	for i, r := range t.population {
+		if i == 0 || top.Score < r.Score {
+			top = r
 		}
 
-		if lastState.Snake.Head.X == st.Snake.Head.X && lastState.Snake.Head.Y == st.Snake.Head.Y {
-			continue
+		if i == 0 || (len(t.p.Scoring) > 0 && best.Fitness < r.Fitness) {
+			best = r
 		}
+	}
 
-		in := createInput(st)
-		out := n.predict(in)
+	if len(t.p.Scoring) == 0 || t.bestBrain.Score < top.Score {
+		return top
+	}
 
-		var direction int
-		var m float64
-		for i, v := range out {
-			if i == 0 || v > m {
-				direction = i
-				m = v
-			}
-		}
+	return best
+}
 
-		switch direction {
-		case 0:
-			key = termbox.KeyArrowRight
-		case 1:
-			key = termbox.KeyArrowLeft
-		case 2:
-			key = termbox.KeyArrowUp
-		case 3:
-			key = termbox.KeyArrowDown
+func (t *trainer) stat() state.Stat {
+	return state.Stat{
+		Epoch:         t.epoch,
+		Instance:      t.instance,
+		BestScore:     t.bestBrain.Score,
+		MaxEpochScore: t.maxEpochScore,
+	}
+}
+
+// Play lets the brain steer the second snake against a human, it never learns
+func Play(p state.Parameters, ch chan state.SnakeGame, pad chan snake.KeyboardEvent) error {
+	brain, err := loadBrain(p)
+	if err != nil {
+		return fmt.Errorf("failed to load brain, %s", err)
+	}
+
+	var lastKey termbox.Key
+
+	for st := range ch {
+		if st.IsOver {
+			lastKey = 0
+			continue
 		}
 
+		key := brain.Neuronet.decide(st)
+
 		if lastKey != key {
-			pad <- snake.KeyboardEvent{EventType: snake.MOVE, Key: key}
+			pad <- snake.KeyboardEvent{EventType: snake.MOVE, Key: key, Rival: true}
 		}
 
 		lastKey = key
@@ -165,37 +221,60 @@ type neuronet struct {
 	BiasOut       [4]float64
 }
 
-func (n *neuronet) randFill() {
-	rand.Seed(time.Now().UnixNano())
-
+func (n *neuronet) randFill(r *rand.Rand) {
 	for i := range n.WeightHidden1 {
 		for j := range n.WeightHidden1[i] {
-			n.WeightHidden1[i][j] = randFloat(-1, 1)
+			n.WeightHidden1[i][j] = randFloat(r, -1, 1)
 		}
 	}
 
 	for i := range n.BiasHidden1 {
-		n.BiasHidden1[i] = randFloat(-1, 1)
+		n.BiasHidden1[i] = randFloat(r, -1, 1)
 	}
 
 	for i := range n.WeightHidden2 {
 		for j := range n.WeightHidden2[i] {
-			n.WeightHidden2[i][j] = randFloat(-1, 1)
+			n.WeightHidden2[i][j] = randFloat(r, -1, 1)
 		}
 	}
 
 	for i := range n.BiasHidden2 {
-		n.BiasHidden2[i] = randFloat(-1, 1)
+		n.BiasHidden2[i] = randFloat(r, -1, 1)
 	}
 
 	for i := range n.WeightOutput {
 		for j := range n.WeightOutput[i] {
-			n.WeightOutput[i][j] = randFloat(-1, 1)
+			n.WeightOutput[i][j] = randFloat(r, -1, 1)
 		}
 	}
 
 	for i := range n.BiasOut {
-		n.BiasOut[i] = randFloat(-1, 1)
+		n.BiasOut[i] = randFloat(r, -1, 1)
+	}
+}
+
+// decide picks the arrow key for the most likely direction
+func (n *neuronet) decide(st state.SnakeGame) termbox.Key {
+	out := n.predict(createInput(st))
+
+	var direction int
+	var m float64
+	for i, v := range out {
+		if i == 0 || v > m {
+			direction = i
+			m = v
+		}
+	}
+
+	switch direction {
+	case 0:
+		return termbox.KeyArrowRight
+	case 1:
+		return termbox.KeyArrowLeft
+	case 2:
+		return termbox.KeyArrowUp
+	default:
+		return termbox.KeyArrowDown
 	}
 }
 
@@ -245,10 +324,8 @@ func (n *neuronet) predict(input [24]float64) []float64 {
 	return SoftMax(t0[:])
 }
 
-func randFloat(min, max float64) float64 {
-	rand.Seed(time.Now().UnixNano())
-
-	return min + rand.Float64()*(max-min)
+func randFloat(r *rand.Rand, min, max float64) float64 {
+	return min + r.Float64()*(max-min)
 }
 
 func ReLU(x float64) float64 {
@@ -314,6 +391,10 @@ func createInput(st state.SnakeGame) [24]float64 {
 }
 
 func distanceHead2Arena(st state.SnakeGame) [8]float64 {
+	if st.Arena.Wrap { // no walls to bump into
+		return [8]float64{}
+	}
+
 	n := st.Arena.Height - st.Snake.Head.Y
 	e := st.Arena.Width - st.Snake.Head.X
 	s := st.Snake.Head.Y
@@ -322,18 +403,47 @@ func distanceHead2Arena(st state.SnakeGame) [8]float64 {
 	return distanceCardinalDirection(n, e, s, w)
 }
 
+func nearestFood(st state.SnakeGame) (state.Food, bool) {
+	var (
+		nearest state.Food
+		min     = -1
+	)
+
+	for _, f := range st.Foods {
+		d := abs(f.X-st.Snake.Head.X) + abs(f.Y-st.Snake.Head.Y)
+		if min == -1 || d < min {
+			nearest, min = f, d
+		}
+	}
+
+	return nearest, min != -1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
 func distanceHead2Food(st state.SnakeGame) [8]float64 {
+	food, ok := nearestFood(st)
+	if !ok {
+		return [8]float64{}
+	}
+
 	var n, e, s, w int
-	if st.Snake.Head.X > st.Food.X {
-		w = st.Snake.Head.X - st.Food.X
+	if st.Snake.Head.X > food.X {
+		w = st.Snake.Head.X - food.X
 	} else {
-		e = st.Food.X - st.Snake.Head.X
+		e = food.X - st.Snake.Head.X
 	}
 
-	if st.Snake.Head.Y > st.Food.Y {
-		s = st.Snake.Head.Y - st.Food.Y
+	if st.Snake.Head.Y > food.Y {
+		s = st.Snake.Head.Y - food.Y
 	} else {
-		n = st.Food.Y - st.Snake.Head.Y
+		n = food.Y - st.Snake.Head.Y
 	}
 
 	return distanceCardinalDirection(n, e, s, w)
@@ -341,8 +451,17 @@ func distanceHead2Food(st state.SnakeGame) [8]float64 {
 
 func distanceHead2Body(st state.SnakeGame) [8]float64 {
 	var res [8]float64
-	body := st.Snake.Body
 	head := st.Snake.Head
+
+	// obstacles and the rival are as deadly as the body, so they share the sensor
+	body := make([]state.Coord, 0, len(st.Snake.Body)+len(st.Arena.Obstacles))
+	body = append(body, st.Snake.Body...)
+	body = append(body, st.Arena.Obstacles...)
+
+	if st.Rival != nil {
+		body = append(body, st.Rival.Body...)
+	}
+
 	for i := range body {
 		if head.X == body[i].X && head.Y < body[i].Y { // N ↑
 			res[0] = float64(1) / float64(head.Y-body[i].Y)
@@ -418,43 +537,43 @@ func distanceCardinalDirection(n, e, s, w int) [8]float64 {
 	return res
 }
 
-func mutate(n neuronet, mutationRate, mutationRange float64) neuronet {
+func mutate(r *rand.Rand, n neuronet, mutationRate, mutationRange float64) neuronet {
 	for i := range n.WeightHidden1 {
 		for j := range n.WeightHidden1[i] {
-			n.WeightHidden1[i][j] = mutateNeurone(n.WeightHidden1[i][j], mutationRate, mutationRange)
+			n.WeightHidden1[i][j] = mutateNeurone(r, n.WeightHidden1[i][j], mutationRate, mutationRange)
 		}
 	}
 
 	for i := range n.BiasHidden1 {
-		n.BiasHidden1[i] = mutateNeurone(n.BiasHidden1[i], mutationRate, mutationRange)
+		n.BiasHidden1[i] = mutateNeurone(r, n.BiasHidden1[i], mutationRate, mutationRange)
 	}
 
 	for i := range n.WeightHidden2 {
 		for j := range n.WeightHidden2[i] {
-			n.WeightHidden2[i][j] = mutateNeurone(n.WeightHidden2[i][j], mutationRate, mutationRange)
+			n.WeightHidden2[i][j] = mutateNeurone(r, n.WeightHidden2[i][j], mutationRate, mutationRange)
 		}
 	}
 
 	for i := range n.BiasHidden2 {
-		n.BiasHidden2[i] = mutateNeurone(n.BiasHidden2[i], mutationRate, mutationRange)
+		n.BiasHidden2[i] = mutateNeurone(r, n.BiasHidden2[i], mutationRate, mutationRange)
 	}
 
 	for i := range n.WeightOutput {
 		for j := range n.WeightOutput[i] {
-			n.WeightOutput[i][j] = mutateNeurone(n.WeightOutput[i][j], mutationRate, mutationRange)
+			n.WeightOutput[i][j] = mutateNeurone(r, n.WeightOutput[i][j], mutationRate, mutationRange)
 		}
 	}
 
 	for i := range n.BiasOut {
-		n.BiasOut[i] = mutateNeurone(n.BiasOut[i], mutationRate, mutationRange)
+		n.BiasOut[i] = mutateNeurone(r, n.BiasOut[i], mutationRate, mutationRange)
 	}
 
 	return n
 }
 
-func mutateNeurone(weight, mutationRate, mutationRange float64) float64 {
-	if randFloat(0, 1) <= mutationRate {
-		weight += randFloat(mutationRange*-1, mutationRange)
+func mutateNeurone(r *rand.Rand, weight, mutationRate, mutationRange float64) float64 {
+	if randFloat(r, 0, 1) <= mutationRate {
+		weight += randFloat(r, mutationRange*-1, mutationRange)
 	}
 
 	return weight
@@ -527,41 +646,41 @@ func fileNameWithoutExtension(fileName string) string {
 	return fileName
 }
 
-func crossoverBrain(n1, n2 neuronet) neuronet {
+func crossoverBrain(r *rand.Rand, n1, n2 neuronet) neuronet {
 	next := n1
 
 	for i := range n2.WeightHidden1 {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.WeightHidden1[i] = n2.WeightHidden1[i]
 		}
 	}
 
 	for i := range n2.BiasHidden1 {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.BiasHidden1[i] = n2.BiasHidden1[i]
 		}
 	}
 
 	for i := range n2.WeightHidden2 {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.WeightHidden2[i] = n2.WeightHidden2[i]
 		}
 	}
 
 	for i := range n2.BiasHidden2 {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.BiasHidden2[i] = n2.BiasHidden2[i]
 		}
 	}
 
 	for i := range n2.WeightOutput {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.WeightOutput[i] = n2.WeightOutput[i]
 		}
 	}
 
 	for i := range n2.BiasOut {
-		if randFloat(0, 1) <= 0.5 {
+		if randFloat(r, 0, 1) <= 0.5 {
 			next.BiasOut[i] = n2.BiasOut[i]
 		}
 	}
@@ -571,7 +690,7 @@ func crossoverBrain(n1, n2 neuronet) neuronet {
 
 func CreateBrain(p state.Parameters) error {
 	n := neuronet{}
-	n.randFill()
+	n.randFill(rand.New(rand.NewSource(p.Seed)))
 
 	r := Result{Neuronet: n}
 
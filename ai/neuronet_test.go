@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/imega/snake-game/snake"
+	"github.com/imega/snake-game/state"
+)
+
+func newDoubleTrainingParameters(t *testing.T) state.Parameters {
+	dir, err := ioutil.TempDir("", "brains")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	p := state.Parameters{
+		MaxInstance:    5,
+		MutationRate:   0.5,
+		MutationRange:  0.5,
+		MaxSnakeSteps:  40,
+		PrefixFilename: filepath.Join(dir, "test"),
+		Seed:           3,
+		Width:          12,
+		Height:         8,
+		SnakeLength:    3,
+		StartX:         1,
+		StartY:         1,
+		StartDirection: "right",
+		FoodCount:      3,
+	}
+
+	if err := CreateBrain(p); err != nil {
+		t.Fatal(err)
+	}
+
+	p.BrainFilename = p.PrefixFilename + "-brain-0.json"
+
+	return p
+}
+
+func train(t *testing.T, p state.Parameters, epochs int) *trainer {
+	tr, err := newTrainer(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := snake.NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Reset(p.Seed)
+
+	for tr.epoch < epochs {
+		if err := tr.game(e, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return tr
+}
+
+func TestTrainingWithTheSameSeedGivesTheSameBrains(t *testing.T) {
+	p := newDoubleTrainingParameters(t)
+
+	a := train(t, p, 3)
+	b := train(t, p, 3)
+
+	if !reflect.DeepEqual(a.bestBrain, b.bestBrain) || !reflect.DeepEqual(a.n, b.n) {
+		t.Fatal("Expected two training runs with the same seed to give the same brains")
+	}
+
+	p.Seed++
+	if c := train(t, p, 3); reflect.DeepEqual(a.n, c.n) {
+		t.Fatal("Expected another seed to give other brains")
+	}
+}
+
+func TestNewRejectsGamesThatMayNeverEnd(t *testing.T) {
+	p := newDoubleTrainingParameters(t)
+	p.MaxSnakeSteps = 0
+
+	e, err := snake.NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(p, e, nil); err == nil {
+		t.Fatal("Expected training without max steps to be rejected")
+	}
+}
+
+func TestFittestPrefersARecordScore(t *testing.T) {
+	population := []Result{{Score: 20, Fitness: 5}, {Score: 15, Fitness: 30}}
+
+	cases := []struct {
+		name    string
+		scoring []string
+		best    int
+		want    int
+	}{
+		{"record without scoring", nil, 10, 20},
+		{"record with scoring", []string{"waste"}, 10, 20},
+		{"no record without scoring", nil, 25, 20},
+		{"no record with scoring", []string{"waste"}, 25, 15},
+	}
+
+	for _, c := range cases {
+		tr := &trainer{
+			p:          state.Parameters{Scoring: c.scoring},
+			population: population,
+			bestBrain:  Result{Score: c.best},
+		}
+
+		if got := tr.fittest().Score; got != c.want {
+			t.Errorf("%s: expected the brain scoring %d but got %d", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/imega/snake-game/ai"
+	"github.com/imega/snake-game/snake"
+	"github.com/imega/snake-game/state"
+)
+
+// errUsage asks for the usage of the command to be printed
+var errUsage = errors.New("invalid arguments")
+
+func runPlay(fs *flag.FlagSet, args []string) error {
+	p, cfg, restore, err := parsePlay(fs, args)
+	if err != nil {
+		return err
+	}
+
+	g, err := snake.NewGame(p)
+	if err != nil {
+		return fmt.Errorf("failed to create game, %s", err)
+	}
+
+	if cfg.dump {
+		return dumpConfig(p)
+	}
+
+	if err := restoreGame(g, restore); err != nil {
+		return err
+	}
+
+	ch := make(chan state.SnakeGame)
+
+	if p.Versus {
+		background(func() error {
+			return ai.Play(p, ch, snake.KeyboardEventsChan)
+		})
+	}
+
+	return g.Start(p, ch)
+}
+
+// parsePlay reads the flags and the config file of the play command,
+// a brain given with -vs brings in the second snake whatever the config says
+func parsePlay(fs *flag.FlagSet, args []string) (state.Parameters, *config, string, error) {
+	p := defaultParameters()
+	p.Human = true
+	p.MaxSnakeSteps = 0
+	p.Player = defaultPlayer()
+
+	arenaFlags(fs, &p)
+	terminalFlags(fs, &p)
+	recordFlag(fs, &p)
+	maxStepsFlag(fs, &p)
+	scoresFlag(fs, &p)
+	fs.StringVar(&p.Player, "player", p.Player, "name to keep high scores under, empty keeps none")
+	fs.BoolVar(&p.TwoPlayers, "two", false, "second snake on the same keyboard, arrows against WASD")
+	fs.StringVar(&p.BrainFilename, "vs", "", "play against the brain from the file")
+	restore := restoreFlag(fs)
+	cfg := configFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return p, nil, "", err
+	}
+
+	if fs.NArg() != 0 {
+		return p, nil, "", errUsage
+	}
+
+	if err := cfg.load(fs, &p); err != nil {
+		return p, nil, "", err
+	}
+
+	if p.BrainFilename != "" {
+		p.Versus = true
+		p.TwoPlayers = true
+	}
+
+	return p, cfg, *restore, nil
+}
+
+func runTrain(fs *flag.FlagSet, args []string) error {
+	p := defaultParameters()
+
+	arenaFlags(fs, &p)
+	terminalFlags(fs, &p)
+	recordFlag(fs, &p)
+	trainingFlags(fs, &p)
+	maxStepsFlag(fs, &p)
+	cfg := configFlags(fs)
+
+	if err := parseBrain(fs, &p, args); err != nil {
+		return err
+	}
+
+	if err := cfg.load(fs, &p); err != nil {
+		return err
+	}
+
+	g, err := snake.NewGame(p)
+	if err != nil {
+		return fmt.Errorf("failed to create game, %s", err)
+	}
+
+	if cfg.dump {
+		return dumpConfig(p)
+	}
+
+	if p.Silent {
+		return ai.New(p, g.Engine, nil)
+	}
+
+	return g.Drive(p, func(show func(state.Stat) error) error {
+		return ai.New(p, g.Engine, show)
+	})
+}
+
+func runWatch(fs *flag.FlagSet, args []string) error {
+	p := defaultParameters()
+	p.Watch = true
+
+	arenaFlags(fs, &p)
+	terminalFlags(fs, &p)
+	recordFlag(fs, &p)
+	maxStepsFlag(fs, &p)
+	restore := restoreFlag(fs)
+	cfg := configFlags(fs)
+
+	if err := parseBrain(fs, &p, args); err != nil {
+		return err
+	}
+
+	if err := cfg.load(fs, &p); err != nil {
+		return err
+	}
+
+	g, err := snake.NewGame(p)
+	if err != nil {
+		return fmt.Errorf("failed to create game, %s", err)
+	}
+
+	if cfg.dump {
+		return dumpConfig(p)
+	}
+
+	if err := restoreGame(g, *restore); err != nil {
+		return err
+	}
+
+	ch := make(chan state.SnakeGame)
+
+	background(func() error {
+		return ai.Play(p, ch, snake.KeyboardEventsChan)
+	})
+
+	return g.Start(p, ch)
+}
+
+func runCreateBrain(fs *flag.FlagSet, args []string) error {
+	p := defaultParameters()
+
+	fs.Int64Var(&p.Seed, "seed", 0, "seed for the weights, 0 picks one from the clock")
+	fs.StringVar(&p.PrefixFilename, "prefix", "", "prefix filename with brain")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	seed(&p)
+
+	if err := ai.CreateBrain(p); err != nil {
+		return err
+	}
+
+	prefix := ""
+	if p.PrefixFilename != "" {
+		prefix = p.PrefixFilename + "-"
+	}
+
+	fmt.Printf("brain created: %sbrain-0.json\n", prefix)
+
+	return nil
+}
+
+func runEval(fs *flag.FlagSet, args []string) error {
+	p := defaultParameters()
+
+	var games int
+
+	arenaFlags(fs, &p)
+	maxStepsFlag(fs, &p)
+	recordFlag(fs, &p)
+	fs.IntVar(&games, "games", 10, "number of games to play")
+	cfg := configFlags(fs)
+
+	if err := parseBrain(fs, &p, args); err != nil {
+		return err
+	}
+
+	if games < 1 {
+		return fmt.Errorf("invalid number of games %d, need 1 or more", games)
+	}
+
+	if err := cfg.load(fs, &p); err != nil {
+		return err
+	}
+
+	if cfg.dump {
+		if _, err := snake.NewEngine(p); err != nil {
+			return fmt.Errorf("failed to create game, %s", err)
+		}
+
+		return dumpConfig(p)
+	}
+
+	ev, err := ai.Evaluate(p, games)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range ev.Scores {
+		fmt.Printf("game %d, seed %d: %d\n", i+1, p.Seed+int64(i), s)
+	}
+
+	fmt.Printf("games: %d, best: %d, mean: %.1f, fitness: %.1f, steps: %d\n", games, ev.Best, ev.Mean, ev.Fitness, ev.Steps)
+
+	return nil
+}
+
+func runReplay(fs *flag.FlagSet, args []string) error {
+	var (
+		p    = defaultParameters()
+		tick int
+	)
+
+	terminalFlags(fs, &p)
+	fs.IntVar(&tick, "tick", 0, "tick to start the playback at, paused")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	r, err := snake.LoadReplay(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	pb, err := snake.NewPlayback(r, p)
+	if err != nil {
+		return fmt.Errorf("failed to create playback, %s", err)
+	}
+
+	if tick > 0 {
+		pb.Seek(tick)
+		pb.Pause()
+	}
+
+	return pb.Start()
+}
+
+func runScores(fs *flag.FlagSet, args []string) error {
+	var (
+		p      = defaultParameters()
+		mode   string
+		player string
+		width  int
+		height int
+	)
+
+	scoresFlag(fs, &p)
+	fs.StringVar(&mode, "mode", "", "only the tables of the game mode")
+	fs.StringVar(&player, "player", "", "only the tables of the player")
+	fs.IntVar(&width, "width", 0, "only the tables of the arena width")
+	fs.IntVar(&height, "height", 0, "only the tables of the arena height")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	file := p.Scores
+	if file == "" {
+		f, err := snake.ScoresFile()
+		if err != nil {
+			return err
+		}
+
+		file = f
+	}
+
+	s, err := snake.LoadScores(file)
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for _, t := range s.Tables() {
+		e := t[0]
+		if (mode != "" && e.Mode != mode) || (player != "" && e.Player != player) ||
+			(width != 0 && e.Width != width) || (height != 0 && e.Height != height) {
+			continue
+		}
+
+		if found {
+			fmt.Println()
+		}
+
+		found = true
+
+		fmt.Printf("%s %dx%d, %s\n", e.Mode, e.Width, e.Height, e.Player)
+
+		for i, e := range t {
+			fmt.Printf("%3d. %6d  length %-4d %6d ticks  %-10s  %s\n",
+				i+1, e.Score, e.Length, e.Ticks, e.Death, e.Date.Format("2006-01-02 15:04"))
+		}
+	}
+
+	if !found {
+		fmt.Println("no high scores yet")
+	}
+
+	return nil
+}
+
+func runInspect(fs *flag.FlagSet, args []string) error {
+	p := defaultParameters()
+
+	if err := parseBrain(fs, &p, args); err != nil {
+		return err
+	}
+
+	info, err := ai.Inspect(p)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("brain:   %s\n", p.BrainFilename)
+	fmt.Printf("score:   %d\n", info.Score)
+	fmt.Printf("layers:  %v\n", info.Layers)
+	fmt.Printf("weights: %d, min %.3f, max %.3f, mean %.3f\n", info.Weights, info.Min, info.Max, info.Mean)
+
+	return nil
+}
+
+func restoreFlag(fs *flag.FlagSet) *string {
+	return fs.String("restore", "", "snapshot file to start the game from")
+}
+
+// restoreGame replaces the new game with the snapshot from the file, if any
+func restoreGame(g *snake.Game, filename string) error {
+	if filename == "" {
+		return nil
+	}
+
+	s, err := snake.LoadSnapshot(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := g.Restore(s); err != nil {
+		return fmt.Errorf("failed to restore snapshot, %s", err)
+	}
+
+	return nil
+}
+
+// defaultPlayer is the name high scores are kept under, the login name
+func defaultPlayer() string {
+	if u := os.Getenv("USER"); u != "" {
+		return u
+	}
+
+	return "player"
+}
+
+// parseBrain reads the flags and the brain filename that follows them
+func parseBrain(fs *flag.FlagSet, p *state.Parameters, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	p.BrainFilename = fs.Arg(0)
+
+	return nil
+}
+
+// background runs f next to the game and exits when it fails
+func background(f func() error) {
+	go func() {
+		if err := f(); err != nil {
+			fmt.Printf("failed to start, %s\n", err)
+			os.Exit(1)
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestParsePlayVersusKeepsTheSecondSnake(t *testing.T) {
+	file := newDoubleConfigFile(t, `{"two_players": false}`)
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+
+	p, _, _, err := parsePlay(fs, []string{"-vs", "brain-0.json", "-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.Versus || !p.TwoPlayers || p.BrainFilename != "brain-0.json" {
+		t.Fatalf("Expected -vs to bring in the second snake but got %+v", p)
+	}
+}
+
+func TestParsePlayKeepsConfigWithoutVersus(t *testing.T) {
+	file := newDoubleConfigFile(t, `{"two_players": true}`)
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+
+	p, _, _, err := parsePlay(fs, []string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Versus || !p.TwoPlayers {
+		t.Fatalf("Expected a local two-player game from the config but got %+v", p)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/imega/snake-game/snake"
+	"github.com/imega/snake-game/state"
+)
+
+type config struct {
+	file string
+	dump bool
+}
+
+func configFlags(fs *flag.FlagSet) *config {
+	c := &config{}
+
+	fs.StringVar(&c.file, "config", "", "JSON config file, flags override its values")
+	fs.BoolVar(&c.dump, "dump-config", false, "print the effective config and exit")
+
+	return c
+}
+
+// load applies the level, then the config file and then the flags given
+// on the command line, checks the ranges and picks a seed
+func (c *config) load(fs *flag.FlagSet, p *state.Parameters) error {
+	set := givenFlags(fs)
+
+	if err := applyConfig(fs, p, c.file, set); err != nil {
+		return fmt.Errorf("failed to load config, %s", err)
+	}
+
+	if p.Level != "" {
+		l, err := snake.LoadLevel(p.Level)
+		if err != nil {
+			return fmt.Errorf("failed to load level, %s", err)
+		}
+
+		l.Apply(p)
+
+		if err := applyConfig(fs, p, c.file, set); err != nil {
+			return fmt.Errorf("failed to load config, %s", err)
+		}
+	}
+
+	if err := validateConfig(*p); err != nil {
+		return fmt.Errorf("invalid config, %s", err)
+	}
+
+	seed(p)
+
+	return nil
+}
+
+func seed(p *state.Parameters) {
+	if p.Seed == 0 {
+		p.Seed = time.Now().UnixNano()
+	}
+}
+
+type resetter interface {
+	reset()
+}
+
+// givenFlags returns the values of the flags given on the command line
+func givenFlags(fs *flag.FlagSet) map[string]string {
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	return set
+}
+
+// loadConfig reads the parameters from a JSON config file, the flags
+// given on the command line keep precedence over the file
+func loadConfig(fs *flag.FlagSet, p *state.Parameters, filename string) error {
+	return applyConfig(fs, p, filename, givenFlags(fs))
+}
+
+// applyConfig reads the config file, if any, and sets the given flags again
+func applyConfig(fs *flag.FlagSet, p *state.Parameters, filename string, set map[string]string) error {
+	if filename != "" {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read config, %s", err)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(p); err != nil {
+			return fmt.Errorf("failed to parse config %s, %s", filename, err)
+		}
+	}
+
+	for name, value := range set {
+		v := fs.Lookup(name).Value
+		if r, ok := v.(resetter); ok {
+			r.reset()
+		}
+
+		if err := v.Set(value); err != nil {
+			return fmt.Errorf("failed to apply flag -%s, %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateConfig checks the ranges of the settings the game does not
+// validate itself
+func validateConfig(p state.Parameters) error {
+	switch {
+	case p.Speed < 0:
+		return fmt.Errorf("invalid speed %d, need 0 or more", p.Speed)
+	case p.MaxInstance < 1:
+		return fmt.Errorf("invalid max instance %d, need 1 or more", p.MaxInstance)
+	case p.MutationRate < 0 || p.MutationRate > 1:
+		return fmt.Errorf("invalid mutation rate %g, need 0 to 1", p.MutationRate)
+	case p.MutationRange < 0:
+		return fmt.Errorf("invalid mutation range %g, need 0 or more", p.MutationRange)
+	case p.MaxSnakeSteps < 0:
+		return fmt.Errorf("invalid max snake steps %d, need 0 or more", p.MaxSnakeSteps)
+	case p.MaxTicks < 0:
+		return fmt.Errorf("invalid max ticks %d, need 0 or more", p.MaxTicks)
+	case p.MinScoreEpoch < 0:
+		return fmt.Errorf("invalid min score in epoch %d, need 0 or more", p.MinScoreEpoch)
+	}
+
+	return nil
+}
+
+func dumpConfig(p state.Parameters) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config, %s", err)
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
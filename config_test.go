@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/imega/snake-game/snake"
+	"github.com/imega/snake-game/state"
+)
+
+func newDoubleConfigFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Cleanup(func() {
+		os.Remove(f.Name())
+	})
+
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+
+	return f.Name()
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := map[string]struct {
+		file string
+		args []string
+		want func(p *state.Parameters)
+	}{
+		"file value": {
+			file: `{"width": 40, "height": 16}`,
+			want: func(p *state.Parameters) {
+				p.Width = 40
+				p.Height = 16
+			},
+		},
+		"flag overrides file value": {
+			file: `{"width": 40, "height": 16}`,
+			args: []string{"-width", "30"},
+			want: func(p *state.Parameters) {
+				p.Width = 30
+				p.Height = 16
+			},
+		},
+		"flag given with the default value still overrides": {
+			file: `{"width": 40}`,
+			args: []string{"-width", "50"},
+			want: func(p *state.Parameters) {},
+		},
+		"list flag replaces file list": {
+			file: `{"food_kinds": ["cake", "taco"], "scoring": ["combo"]}`,
+			args: []string{"-food-kinds", "fruit,ice"},
+			want: func(p *state.Parameters) {
+				p.FoodKinds = []string{"fruit", "ice"}
+				p.Scoring = []string{"combo"}
+			},
+		},
+		"cells flag replaces file cells": {
+			file: `{"obstacles": [{"x": 1, "y": 2}, {"x": 3, "y": 4}]}`,
+			args: []string{"-obstacles", "5,6"},
+			want: func(p *state.Parameters) {
+				p.Obstacles = []state.Coord{{X: 5, Y: 6}}
+			},
+		},
+		"list flag given twice": {
+			file: `{"food_kinds": ["cake"]}`,
+			args: []string{"-food-kinds", "fruit", "-food-kinds", "ice"},
+			want: func(p *state.Parameters) {
+				p.FoodKinds = []string{"fruit", "ice"}
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			p := defaultParameters()
+			arenaFlags(fs, &p)
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := loadConfig(fs, &p, newDoubleConfigFile(t, tt.file)); err != nil {
+				t.Fatal(err)
+			}
+
+			want := defaultParameters()
+			tt.want(&want)
+
+			if !reflect.DeepEqual(p, want) {
+				t.Fatalf("Expected %+v but got %+v", want, p)
+			}
+		})
+	}
+}
+
+func TestConfigLoadAppliesTheLevelFirst(t *testing.T) {
+	for name, file := range map[string]string{
+		"level flag":        `{"food_lifetime": 20}`,
+		"level in the file": `{"food_lifetime": 20, "level": "donut"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			p := defaultParameters()
+			arenaFlags(fs, &p)
+			cfg := configFlags(fs)
+
+			args := []string{"-config", newDoubleConfigFile(t, file), "-food", "5", "-width", "30"}
+			if name == "level flag" {
+				args = append(args, "-level", "donut")
+			}
+
+			if err := fs.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := cfg.load(fs, &p); err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := snake.LoadLevel("donut")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Width != 30 || p.FoodCount != 5 || p.FoodLifetime != 20 || p.Height != l.Height {
+				t.Fatalf(
+					"Expected width 30, food 5 and lifetime 20 over the level height %d but got %d, %d, %d and height %d",
+					l.Height, p.Width, p.FoodCount, p.FoodLifetime, p.Height,
+				)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsBadFiles(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":  `{"widht": 40}`,
+		"wrong type":   `{"width": "wide"}`,
+		"invalid json": `{"width": 40`,
+	}
+
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			p := defaultParameters()
+			arenaFlags(fs, &p)
+
+			if err := loadConfig(fs, &p, newDoubleConfigFile(t, file)); err == nil {
+				t.Fatal("Expected the config file to be rejected")
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := map[string]struct {
+		change func(p *state.Parameters)
+		valid  bool
+	}{
+		"defaults":           {func(p *state.Parameters) {}, true},
+		"zero speed":         {func(p *state.Parameters) { p.Speed = 0 }, true},
+		"negative speed":     {func(p *state.Parameters) { p.Speed = -1 }, false},
+		"one instance":       {func(p *state.Parameters) { p.MaxInstance = 1 }, true},
+		"no instances":       {func(p *state.Parameters) { p.MaxInstance = 0 }, false},
+		"mutation rate of 0": {func(p *state.Parameters) { p.MutationRate = 0 }, true},
+		"mutation rate of 1": {func(p *state.Parameters) { p.MutationRate = 1 }, true},
+		"negative rate":      {func(p *state.Parameters) { p.MutationRate = -0.1 }, false},
+		"rate above 1":       {func(p *state.Parameters) { p.MutationRate = 1.1 }, false},
+		"negative range":     {func(p *state.Parameters) { p.MutationRange = -0.5 }, false},
+		"never starving":     {func(p *state.Parameters) { p.MaxSnakeSteps = 0 }, true},
+		"negative max steps": {func(p *state.Parameters) { p.MaxSnakeSteps = -1 }, false},
+		"negative max ticks": {func(p *state.Parameters) { p.MaxTicks = -1 }, false},
+		"negative min score": {func(p *state.Parameters) { p.MinScoreEpoch = -1 }, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := defaultParameters()
+			tt.change(&p)
+
+			if err := validateConfig(p); (err == nil) != tt.valid {
+				t.Fatalf("Expected valid to be %t but got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -4,73 +4,262 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
-	"github.com/imega/snake-game/ai"
 	"github.com/imega/snake-game/snake"
 	"github.com/imega/snake-game/state"
 )
 
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{
+		name:    "play",
+		args:    "[-vs <prefix>brain-<score>.json]",
+		summary: "play in the terminal, alone, against a second player or against a brain",
+		run:     runPlay,
+	},
+	{
+		name:    "train",
+		args:    "<prefix>brain-<score>.json",
+		summary: "train the brain from the file and save every better brain next to it",
+		run:     runTrain,
+	},
+	{
+		name:    "watch",
+		args:    "<prefix>brain-<score>.json",
+		summary: "watch the brain from the file play without learning",
+		run:     runWatch,
+	},
+	{
+		name:    "create-brain",
+		args:    "",
+		summary: "create a brain with random weights as <prefix>brain-0.json",
+		run:     runCreateBrain,
+	},
+	{
+		name:    "eval",
+		args:    "<prefix>brain-<score>.json",
+		summary: "play headless games with the brain from the file and print the scores",
+		run:     runEval,
+	},
+	{
+		name:    "replay",
+		args:    "<replay file>",
+		summary: "play back a recorded game",
+		run:     runReplay,
+	},
+	{
+		name:    "scores",
+		args:    "",
+		summary: "print the high-score tables",
+		run:     runScores,
+	},
+	{
+		name:    "inspect",
+		args:    "<prefix>brain-<score>.json",
+		summary: "print the layers and weight statistics of the brain from the file",
+		run:     runInspect,
+	},
+}
+
 func main() {
-	p := state.Parameters{}
-
-	flag.IntVar(&p.Speed, "s", 100, "snake speed limit")
-	flag.IntVar(&p.MaxInstance, "i", 1000, "max number of instances in epoch")
-	flag.Float64Var(&p.MutationRate, "r", 0.1, "mutation rate on the weights of synapses")
-	flag.Float64Var(&p.MutationRange, "n", 0.5, "interval of the mutation changes on the synapse weight")
-	flag.IntVar(&p.MaxSnakeSteps, "t", 200, "max snake stept without eat")
-	flag.IntVar(&p.MinScoreEpoch, "m", 0, "min score in epoch")
-	flag.StringVar(&p.PrefixFilename, "p", "", "prefix filename with brain")
-	flag.BoolVar(&p.Silent, "q", false, "start in silent mode")
-	flag.BoolVar(&p.Human, "h", false, "start in human mode")
-	flag.BoolVar(&p.CreateBrain, "c", false, "create empty brain")
-	flag.Parse()
-
-	if p.CreateBrain {
-		if err := ai.CreateBrain(p); err != nil {
-			fmt.Printf("failed to create brain, %s\n", err)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) == 0 {
 			usage()
-			os.Exit(1)
+			os.Exit(0)
 		}
 
-		prefix := ""
-		if p.PrefixFilename != "" {
-			prefix = p.PrefixFilename + "-"
-		}
+		name, args = args[0], []string{"-h"}
+	}
+
+	c := findCommand(name)
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s\n\n", os.Args[0], c.name, c.args, c.summary)
+		fs.PrintDefaults()
+	}
 
-		fmt.Printf("brain created: %sbrain-0.json\n", prefix)
+	err := c.run(fs, args)
+	switch {
+	case err == flag.ErrHelp:
 		os.Exit(0)
+	case err == errUsage:
+		fs.Usage()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "failed to %s, %s\n", c.name, err)
+		os.Exit(1)
 	}
+}
 
-	ch := make(chan state.SnakeGame)
-	statCh := make(chan state.Stat)
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
 
-	if !p.Human {
-		args := flag.Args()
-		if len(args) == 0 {
-			fmt.Printf("empty args filename\n")
-			usage()
-			os.Exit(1)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", os.Args[0])
+
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n", c.name, c.summary)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nRun %s help <command> for the flags of a command.\n", os.Args[0])
+}
+
+func defaultParameters() state.Parameters {
+	return state.Parameters{
+		Speed:          100,
+		MaxInstance:    1000,
+		MutationRate:   0.1,
+		MutationRange:  0.5,
+		MaxSnakeSteps:  200,
+		Width:          50,
+		Height:         20,
+		SnakeLength:    4,
+		StartX:         1,
+		StartY:         1,
+		StartDirection: "right",
+		FoodCount:      1,
+	}
+}
+
+func arenaFlags(fs *flag.FlagSet, p *state.Parameters) {
+	fs.Int64Var(&p.Seed, "seed", p.Seed, "seed for games and training, 0 picks one from the clock")
+	fs.IntVar(&p.Width, "width", p.Width, "arena width")
+	fs.IntVar(&p.Height, "height", p.Height, "arena height")
+	fs.IntVar(&p.SnakeLength, "length", p.SnakeLength, "starting snake length")
+	fs.IntVar(&p.StartX, "x", p.StartX, "starting snake tail column")
+	fs.IntVar(&p.StartY, "y", p.StartY, "starting snake tail row")
+	fs.StringVar(&p.StartDirection, "dir", p.StartDirection, "starting snake direction: right, left, up or down")
+	fs.BoolVar(&p.Wrap, "wrap", p.Wrap, "leaving the arena re-enters from the opposite edge")
+	fs.Var((*coords)(&p.Obstacles), "obstacles", "obstacle cells as space separated x,y pairs")
+	fs.IntVar(&p.FoodCount, "food", p.FoodCount, "number of food items on the board")
+	fs.Var((*list)(&p.FoodKinds), "food-kinds", "comma separated food kinds to spawn, power-ups only when named: "+strings.Join(snake.FoodKinds(), ", "))
+	fs.IntVar(&p.FoodLifetime, "food-lifetime", p.FoodLifetime, "ticks before food without its own lifetime expires, 0 keeps it forever")
+	fs.StringVar(&p.Level, "level", p.Level, "level file or built-in level: "+strings.Join(snake.Levels(), ", "))
+	fs.StringVar(&p.Mode, "mode", p.Mode, "game mode: "+strings.Join(snake.Modes(), ", ")+" (default \"endless\")")
+	fs.IntVar(&p.TimeLimit, "time-limit", p.TimeLimit, "seconds of game time in time-attack mode (default 60)")
+	fs.IntVar(&p.Target, "target", p.Target, "score to reach in target mode (default 200)")
+	fs.Var((*list)(&p.Scoring), "scoring", "comma separated scoring rules on top of the food points: "+strings.Join(snake.ScoreRules(), ", "))
+}
+
+func terminalFlags(fs *flag.FlagSet, p *state.Parameters) {
+	fs.IntVar(&p.Speed, "speed", p.Speed, "milliseconds per tick, 0 runs as fast as possible")
+	fs.StringVar(&p.Keys, "keys", p.Keys, "key-binding file or preset: "+strings.Join(snake.KeymapPresets(), ", "))
+	fs.StringVar(&p.Snapshot, "snapshot", p.Snapshot, "file the save and load keys keep the game in (default \"snake-snapshot.json\")")
+}
+
+func trainingFlags(fs *flag.FlagSet, p *state.Parameters) {
+	fs.IntVar(&p.MaxInstance, "instances", p.MaxInstance, "max number of instances in epoch")
+	fs.Float64Var(&p.MutationRate, "mutation-rate", p.MutationRate, "mutation rate on the weights of synapses")
+	fs.Float64Var(&p.MutationRange, "mutation-range", p.MutationRange, "interval of the mutation changes on the synapse weight")
+	fs.IntVar(&p.MinScoreEpoch, "min-score", p.MinScoreEpoch, "min score in epoch")
+	fs.StringVar(&p.PrefixFilename, "prefix", p.PrefixFilename, "prefix filename with brain")
+	fs.BoolVar(&p.Silent, "silent", p.Silent, "print progress instead of drawing the game")
+}
+
+func recordFlag(fs *flag.FlagSet, p *state.Parameters) {
+	fs.StringVar(&p.Record, "record", p.Record, "directory to write a replay of every finished game into")
+}
+
+func scoresFlag(fs *flag.FlagSet, p *state.Parameters) {
+	fs.StringVar(&p.Scores, "scores", p.Scores, "high-score file (default $XDG_DATA_HOME/snake-game/scores.json)")
+}
+
+func maxStepsFlag(fs *flag.FlagSet, p *state.Parameters) {
+	fs.IntVar(&p.MaxSnakeSteps, "max-steps", p.MaxSnakeSteps, "max snake steps without eating before it starves, 0 never starves")
+	fs.IntVar(&p.MaxTicks, "max-ticks", p.MaxTicks, "ticks before the game runs out of time, 0 never does")
+}
+
+type coords []state.Coord
+
+func (c *coords) String() string {
+	if c == nil {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(*c))
+	for _, v := range *c {
+		pairs = append(pairs, fmt.Sprintf("%d,%d", v.X, v.Y))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+func (c *coords) reset() {
+	*c = nil
+}
+
+func (c *coords) Set(value string) error {
+	for _, pair := range strings.Fields(value) {
+		xy := strings.Split(pair, ",")
+		if len(xy) != 2 {
+			return fmt.Errorf("invalid cell %q, need x,y", pair)
+		}
+
+		x, err := strconv.Atoi(xy[0])
+		if err != nil {
+			return fmt.Errorf("invalid column in %q, %s", pair, err)
 		}
 
-		p.BrainFilename = args[0]
+		y, err := strconv.Atoi(xy[1])
+		if err != nil {
+			return fmt.Errorf("invalid row in %q, %s", pair, err)
+		}
 
-		go func() {
-			if err := ai.New(p, ch, snake.KeyboardEventsChan, statCh); err != nil {
-				fmt.Printf("failed to start, %s\n", err)
-				usage()
-				os.Exit(1)
-			}
-		}()
+		*c = append(*c, state.Coord{X: x, Y: y})
 	}
 
-	snake.NewGame().Start(p, ch, statCh)
+	return nil
 }
 
-func usage() {
-	fmt.Fprintf(
-		flag.CommandLine.Output(),
-		"\nUsage: %s [-chiqmnprst] [<prefix>brain-<score>.json]\n",
-		os.Args[0],
-	)
-	flag.PrintDefaults()
+type list []string
+
+func (l *list) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+func (l *list) reset() {
+	*l = nil
+}
+
+func (l *list) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
 }
@@ -1,84 +1,326 @@
 package snake
 
-import (
-	"math/rand"
-	"time"
-)
+import "math/rand"
 
 type arena struct {
-	food       *food
-	snake      *snake
-	hasFood    func(*arena, coord) bool
-	height     int
-	width      int
-	pointsChan chan (int)
+	foods        []*food
+	snake        *snake
+	rival        *snake
+	obstacles    []coord
+	findFood     func(*arena, coord) int
+	rnd          *rand.Rand
+	height       int
+	width        int
+	wrap         bool
+	foodCount    int
+	foodPoints   int
+	foodLifetime int
+	foodKinds    []*foodKind
+	cells        *cells
 }
 
-func newArena(s *snake, p chan (int), h, w int) *arena {
-	rand.Seed(time.Now().UnixNano())
+type arenaOption func(*arena)
 
+func withObstacles(obstacles ...coord) arenaOption {
+	return func(a *arena) {
+		a.obstacles = obstacles
+	}
+}
+
+func withWrap(wrap bool) arenaOption {
+	return func(a *arena) {
+		a.wrap = wrap
+	}
+}
+
+func withFood(count, points int) arenaOption {
+	return func(a *arena) {
+		if count > 0 {
+			a.foodCount = count
+		}
+
+		if points > 0 {
+			a.foodPoints = points
+		}
+	}
+}
+
+// withFoodLifetime makes food that would stay forever expire after the given ticks
+func withFoodLifetime(ticks int) arenaOption {
+	return func(a *arena) {
+		a.foodLifetime = ticks
+	}
+}
+
+// withRival adds a second snake to the arena
+func withRival(s *snake) arenaOption {
+	return func(a *arena) {
+		a.rival = s
+	}
+}
+
+func withFoodKinds(kinds ...*foodKind) arenaOption {
+	return func(a *arena) {
+		if len(kinds) > 0 {
+			a.foodKinds = kinds
+		}
+	}
+}
+
+func newArena(s *snake, r *rand.Rand, h, w int, opts ...arenaOption) *arena {
 	a := &arena{
-		snake:      s,
-		height:     h,
-		width:      w,
-		pointsChan: p,
-		hasFood:    hasFood,
+		snake:     s,
+		rnd:       r,
+		height:    h,
+		width:     w,
+		findFood:  findFood,
+		foodCount: 1,
+	}
+
+	a.foodKinds, _ = lookupFoodKinds(nil)
+
+	for _, opt := range opts {
+		opt(a)
 	}
 
-	a.placeFood()
+	a.track()
+	a.refill()
 
 	return a
 }
 
+// track counts the cells covered by the obstacles, the snakes and the food
+// from scratch
+func (a *arena) track() {
+	a.cells = newCells(a.width, a.height)
+
+	for _, o := range a.obstacles {
+		a.cells.occupy(o)
+	}
+
+	for _, s := range a.players() {
+		for _, b := range s.body {
+			a.cells.occupy(b)
+		}
+	}
+
+	for _, f := range a.foods {
+		a.cells.occupy(coord{x: f.x, y: f.y})
+	}
+}
+
+// refill places food until there is as much as the arena holds or no
+// free cell is left
+func (a *arena) refill() {
+	for len(a.foods) < a.foodCount {
+		f := a.placeFood()
+		if f == nil {
+			return
+		}
+
+		a.foods = append(a.foods, f)
+	}
+}
+
+// isCleared tells whether the snakes cover every cell that is not an
+// obstacle, no cell is free and no food is left to eat
+func (a *arena) isCleared() bool {
+	return a.cells.free == 0 && len(a.foods) == 0
+}
+
+// players returns the snakes in the arena, the first one is always there
+func (a *arena) players() []*snake {
+	if a.rival == nil {
+		return []*snake{a.snake}
+	}
+
+	return []*snake{a.snake, a.rival}
+}
+
+// moveSnake moves every snake one cell and returns the death of the first one
 func (a *arena) moveSnake() error {
-	if err := a.snake.move(); err != nil {
+	return a.moveSnakes()[0]
+}
+
+// moveSnakes moves every snake one cell and returns what killed each of them,
+// a snake dies when its head lands on any body, the other snake's head included
+func (a *arena) moveSnakes() []error {
+	players := a.players()
+	errs := make([]error, len(players))
+
+	for i, s := range players {
+		errs[i] = a.move(s)
+	}
+
+	for i, s := range players {
+		if errs[i] != nil {
+			continue
+		}
+
+		for j, o := range players {
+			if i != j && o.isOnPosition(s.head()) && !s.has(invincible) {
+				errs[i] = s.die(DeathSnake)
+			}
+		}
+	}
+
+	for _, s := range players {
+		s.wear()
+	}
+
+	a.expireFood()
+	a.refill()
+
+	return errs
+}
+
+func (a *arena) move(s *snake) error {
+	s.eaten = nil
+	s.applyTurn()
+
+	next := s.head().next(s.direction)
+	if a.wrap || s.has(invincible) {
+		next = a.wrapped(next)
+	}
+
+	tail, n := s.body[0], len(s.body)
+	if err := s.moveTo(next); err != nil {
 		return err
 	}
 
-	if a.snakeLeftArena() {
-		return a.snake.die()
+	a.cells.occupy(next)
+	if len(s.body) == n {
+		a.cells.release(tail)
 	}
 
-	if a.hasFood(a, a.snake.head()) {
-		go a.addPoints(a.food.points)
-		a.snake.steps = 0
-		a.snake.length++
-		a.placeFood()
+	if a.isOutside(s.head()) {
+		return s.die(DeathWall)
 	}
 
-	a.snake.steps++
+	if a.isObstacle(s.head()) && !s.has(invincible) {
+		return s.die(DeathObstacle)
+	}
+
+	if i := a.findFood(a, s.head()); i >= 0 {
+		s.eaten = a.foods[i]
+		if s.steps > comboWindow {
+			s.combo = 0
+		}
+
+		if s.eaten.points > 0 {
+			s.combo++
+		}
+
+		s.steps = 0
+		a.grow(s, s.eaten.kind.growth)
+		if a.replaceFood(i) {
+			a.foods[i].fresh = true
+		}
+	}
+
+	s.steps++
 
 	return nil
 }
 
-func (a *arena) snakeLeftArena() bool {
-	h := a.snake.head()
-	return h.x > a.width || h.y > a.height || h.x < 0 || h.y < 0
+// grow changes the snake length and uncovers the cells of a cut tail
+func (a *arena) grow(s *snake, n int) {
+	body := s.body
+	s.grow(n)
+
+	for _, c := range body[:len(body)-len(s.body)] {
+		a.cells.release(c)
+	}
 }
 
-func (a *arena) addPoints(p int) {
-	a.pointsChan <- p
+// replaceFood takes the food off its cell and puts new food on a free
+// one, it tells whether there was a free cell or the food is gone
+func (a *arena) replaceFood(i int) bool {
+	old := a.foods[i]
+	a.cells.release(coord{x: old.x, y: old.y})
+
+	f := a.placeFood()
+	if f == nil {
+		a.foods = append(a.foods[:i], a.foods[i+1:]...)
+		return false
+	}
+
+	a.foods[i] = f
+
+	return true
+}
+
+func (a *arena) isOutside(c coord) bool {
+	return c.x >= a.width || c.y >= a.height || c.x < 0 || c.y < 0
+}
+
+// wrapped brings a cell that left the arena back in from the opposite edge
+func (a *arena) wrapped(c coord) coord {
+	c.x = (c.x%a.width + a.width) % a.width
+	c.y = (c.y%a.height + a.height) % a.height
+
+	return c
+}
+
+// placeFood puts new food on a random free cell, it returns nil when
+// no cell is free
+func (a *arena) placeFood() *food {
+	if a.cells.free == 0 {
+		return nil
+	}
+
+	c := a.cells.nth(a.rnd.Intn(a.cells.free))
+	x, y := c.x, c.y
+	a.cells.occupy(c)
+
+	f := newFoodOfKind(a.rnd, randomFoodKind(a.rnd, a.foodKinds), x, y)
+	if a.foodPoints > 0 && f.kind == &foodKinds[0] {
+		f.points = a.foodPoints
+	}
+
+	if f.ttl == 0 {
+		f.ttl = a.foodLifetime
+	}
+
+	return f
 }
 
-func (a *arena) placeFood() {
-	var x, y int
+// expireFood replaces the food whose lifetime is over, food that has
+// just replaced an eaten one is not counted down before its first tick
+func (a *arena) expireFood() {
+	for i := 0; i < len(a.foods); i++ {
+		if a.foods[i].fresh {
+			a.foods[i].fresh = false
+			continue
+		}
 
-	for {
-		x = rand.Intn(a.width)
-		y = rand.Intn(a.height)
+		if a.foods[i].tick() && !a.replaceFood(i) {
+			i--
+		}
+	}
+}
 
-		if !a.isOccupied(coord{x: x, y: y}) {
-			break
+// findFood returns the index of the food on the cell or -1
+func findFood(a *arena, c coord) int {
+	for i, f := range a.foods {
+		if c.x == f.x && c.y == f.y {
+			return i
 		}
 	}
 
-	a.food = newFood(x, y)
+	return -1
 }
 
-func hasFood(a *arena, c coord) bool {
-	return c.x == a.food.x && c.y == a.food.y
+func (a *arena) isObstacle(c coord) bool {
+	for _, o := range a.obstacles {
+		if o.x == c.x && o.y == c.y {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (a *arena) isOccupied(c coord) bool {
-	return a.snake.isOnPosition(c)
+	return !a.cells.isFree(c)
 }
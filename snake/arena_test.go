@@ -1,12 +1,19 @@
 package snake
 
-import "testing"
-
-var pointsDouble = make(chan int)
+import (
+	"math/rand"
+	"testing"
+)
 
 func newDoubleArenaWithFoodFinder(h, w int, f func(*arena, coord) bool) *arena {
 	a := newDoubleArena(h, w)
-	a.hasFood = f
+	a.findFood = func(a *arena, c coord) int {
+		if f(a, c) {
+			return 0
+		}
+
+		return -1
+	}
 	return a
 }
 
@@ -19,11 +26,11 @@ func newDoubleArena(h, w int) *arena {
 		coord{x: 1, y: 4},
 	})
 
-	return newArena(s, pointsDouble, h, w)
+	return newArena(s, rand.New(rand.NewSource(1)), h, w, withFoodKinds(&foodKinds[0]))
 }
 
 func TestArenaHaveFoodPlaced(t *testing.T) {
-	if a := newDoubleArena(20, 20); a.food == nil {
+	if a := newDoubleArena(20, 20); len(a.foods) != 1 || a.foods[0] == nil {
 		t.Fatal("Arena expected to have food placed")
 	}
 }
@@ -32,8 +39,8 @@ func TestMoveSnakeOutOfArenaHeightLimit(t *testing.T) {
 	a := newDoubleArena(4, 10)
 	a.snake.changeDirection(UP)
 
-	if err := a.moveSnake(); err == nil || err.Error() != "Died" {
-		t.Fatal("Expected Snake to die when moving outside the Arena height limits")
+	if err := a.moveSnake(); deathCause(err) != DeathWall {
+		t.Fatalf("Expected Snake to hit the wall when moving outside the Arena limits but got %v", err)
 	}
 }
 
@@ -41,8 +48,68 @@ func TestMoveSnakeOutOfArenaWidthLimit(t *testing.T) {
 	a := newDoubleArena(10, 1)
 	a.snake.changeDirection(LEFT)
 
-	if err := a.moveSnake(); err == nil || err.Error() != "Died" {
-		t.Fatal("Expected Snake to die when moving outside the Arena height limits")
+	if err := a.moveSnake(); deathCause(err) != DeathWall {
+		t.Fatalf("Expected Snake to hit the wall when moving outside the Arena limits but got %v", err)
+	}
+}
+
+func TestMoveSnakeWrapsAroundArena(t *testing.T) {
+	a := newDoubleArena(10, 3)
+	a.wrap = true
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected Snake to survive leaving a wrapping Arena but got %s", err)
+	}
+
+	if h := a.snake.head(); h.x != 2 || h.y != 4 {
+		t.Fatalf("Expected head at [2 4] but got %v", h)
+	}
+
+	a.moveSnake()
+
+	if h := a.snake.head(); h.x != 0 || h.y != 4 {
+		t.Fatalf("Expected head to re-enter at [0 4] but got %v", h)
+	}
+}
+
+func TestMoveSnakeWrapsAroundArenaBottom(t *testing.T) {
+	a := newDoubleArena(10, 3)
+	a.wrap = true
+	a.snake.body = []coord{{x: 1, y: 2}, {x: 1, y: 1}, {x: 1, y: 0}}
+	a.snake.length = 3
+	a.snake.direction = DOWN
+
+	a.moveSnake()
+
+	if h := a.snake.head(); h.x != 1 || h.y != 9 {
+		t.Fatalf("Expected head to re-enter at [1 9] but got %v", h)
+	}
+}
+
+func TestMoveSnakeIntoObstacle(t *testing.T) {
+	a := newDoubleArena(10, 10)
+	a.obstacles = []coord{{x: 2, y: 4}}
+
+	if err := a.moveSnake(); deathCause(err) != DeathObstacle {
+		t.Fatalf("Expected Snake to die when moving into an obstacle but got %v", err)
+	}
+}
+
+func TestPlaceFoodAvoidsObstacles(t *testing.T) {
+	var obstacles []coord
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			if x != 7 || y != 7 {
+				obstacles = append(obstacles, coord{x: x, y: y})
+			}
+		}
+	}
+
+	s := newSnake(RIGHT, []coord{{x: 7, y: 8}})
+	a := newArena(s, rand.New(rand.NewSource(1)), 10, 10, withObstacles(obstacles...))
+
+	if f := a.foods[0]; f.x != 7 || f.y != 7 {
+		t.Fatalf("Expected food on the only free cell [7 7] but got [%d %d]", f.x, f.y)
 	}
 }
 
@@ -51,11 +118,11 @@ func TestPlaceNewFoodWhenEatFood(t *testing.T) {
 		return true
 	})
 
-	f := a.food
+	f := a.foods[0]
 
 	a.moveSnake()
 
-	if a.food.x == f.x && a.food.y == f.y {
+	if a.foods[0].x == f.x && a.foods[0].y == f.y {
 		t.Fatal("Expected new food to have been placed on Arena")
 	}
 }
@@ -74,31 +141,30 @@ func TestIncreaseSnakeLengthWhenEatFood(t *testing.T) {
 	}
 }
 
-func TestAddPointsWhenEatFood(t *testing.T) {
+func TestReportEatenFoodWhenEatFood(t *testing.T) {
 	a := newDoubleArenaWithFoodFinder(10, 10, func(*arena, coord) bool {
 		return true
 	})
 
-	if p, ok := <-pointsDouble; ok && p != a.food.points {
-		t.Fatalf("Value %d was expected but got %d", a.food.points, p)
-	}
+	f := a.foods[0]
 
 	a.moveSnake()
+
+	if a.snake.eaten != f {
+		t.Fatal("Expected eaten food to have been reported")
+	}
 }
 
-func TestDoesNotAddPointsWhenFoodNotFound(t *testing.T) {
+func TestDoesNotReportEatenFoodWhenFoodNotFound(t *testing.T) {
 	a := newDoubleArenaWithFoodFinder(10, 10, func(*arena, coord) bool {
 		return false
 	})
 
-	select {
-	case p, _ := <-pointsChan:
-		t.Fatalf("No point was expected to be received but received %d", p)
-	default:
-		close(pointsChan)
-	}
-
 	a.moveSnake()
+
+	if a.snake.eaten != nil {
+		t.Fatal("No eaten food was expected to be reported")
+	}
 }
 
 func TestDoesNotPlaceNewFoodWhenFoodNotFound(t *testing.T) {
@@ -106,15 +172,74 @@ func TestDoesNotPlaceNewFoodWhenFoodNotFound(t *testing.T) {
 		return false
 	})
 
-	f := a.food
+	f := a.foods[0]
 
 	a.moveSnake()
 
-	if a.food.x != f.x || a.food.y != f.y {
+	if a.foods[0].x != f.x || a.foods[0].y != f.y {
 		t.Fatal("Food in Arena expected not to have changed")
 	}
 }
 
+func TestShrinkSnakeWhenEatShrinkingFood(t *testing.T) {
+	a := newDoubleArenaWithFoodFinder(10, 10, func(*arena, coord) bool {
+		return true
+	})
+	a.foods[0] = newFoodOfKind(a.rnd, findFoodKind("mushroom"), 2, 4)
+
+	a.moveSnake()
+
+	if a.snake.length != 3 || len(a.snake.body) != 3 {
+		t.Fatalf("Expected Snake to have shrunk to 3 but got %d", len(a.snake.body))
+	}
+
+	if h := a.snake.head(); h.x != 2 || h.y != 4 {
+		t.Fatalf("Expected head to stay at [2 4] but got %v", h)
+	}
+}
+
+func TestReplaceExpiredFood(t *testing.T) {
+	s := newSnake(RIGHT, []coord{{x: 1, y: 1}})
+	a := newArena(s, rand.New(rand.NewSource(1)), 10, 10, withFoodKinds(&foodKinds[0]), withFoodLifetime(2))
+
+	f := a.foods[0]
+	if f.ttl != 2 {
+		t.Fatalf("Expected food to live for 2 ticks but got %d", f.ttl)
+	}
+
+	a.snake.changeDirection(UP)
+	a.moveSnake()
+
+	if a.foods[0] != f || f.ttl != 1 {
+		t.Fatal("Expected food to stay one more tick")
+	}
+
+	a.moveSnake()
+
+	if a.foods[0] == f || a.foods[0].ttl != 2 {
+		t.Fatal("Expected expired food to have been replaced")
+	}
+}
+
+func TestFoodOfAMealLivesItsFullLifetime(t *testing.T) {
+	s := newSnake(RIGHT, []coord{{x: 1, y: 1}})
+	a := newArena(s, rand.New(rand.NewSource(1)), 10, 10, withFoodKinds(&foodKinds[0]), withFoodLifetime(2))
+	a.foods[0].x, a.foods[0].y = 2, 1
+	a.track()
+
+	a.moveSnake()
+
+	if f := a.foods[0]; f.ttl != 2 || f.fresh {
+		t.Fatalf("Expected the new food to show its full lifetime of 2 but got %d", f.ttl)
+	}
+
+	a.moveSnake()
+
+	if f := a.foods[0]; f.ttl != 1 {
+		t.Fatalf("Expected the new food to count down from the next tick but got %d", f.ttl)
+	}
+}
+
 func TestDoesNotIncreaseSnakeLengthWhenFoodNotFound(t *testing.T) {
 	a := newDoubleArenaWithFoodFinder(10, 10, func(*arena, coord) bool {
 		return false
@@ -131,16 +256,111 @@ func TestDoesNotIncreaseSnakeLengthWhenFoodNotFound(t *testing.T) {
 
 func TestHasFood(t *testing.T) {
 	a := newDoubleArena(20, 20)
+	f := a.foods[0]
 
-	if !hasFood(a, coord{x: a.food.x, y: a.food.y}) {
+	if findFood(a, coord{x: f.x, y: f.y}) != 0 {
 		t.Fatal("Food expected to be found")
 	}
 }
 
 func TestHasNotFood(t *testing.T) {
 	a := newDoubleArena(20, 20)
+	f := a.foods[0]
 
-	if hasFood(a, coord{x: a.food.x - 1, y: a.food.y}) {
+	if findFood(a, coord{x: f.x - 1, y: f.y}) != -1 {
 		t.Fatal("No food expected to be found")
 	}
 }
+
+func TestArenaHaveSeveralFoodPlaced(t *testing.T) {
+	s := newSnake(RIGHT, []coord{{x: 1, y: 1}})
+	a := newArena(s, rand.New(rand.NewSource(1)), 10, 10, withFood(5, 0))
+
+	if len(a.foods) != 5 {
+		t.Fatalf("Expected 5 food items but got %d", len(a.foods))
+	}
+
+	for i, f := range a.foods {
+		if findFood(a, coord{x: f.x, y: f.y}) != i {
+			t.Fatal("Expected every food item on its own cell")
+		}
+	}
+}
+
+func TestReplaceOnlyEatenFood(t *testing.T) {
+	s := newSnake(RIGHT, []coord{{x: 1, y: 1}})
+	a := newArena(s, rand.New(rand.NewSource(1)), 10, 10, withFood(3, 0))
+	a.foods[1] = newFood(a.rnd, 2, 1)
+
+	first, last := a.foods[0], a.foods[2]
+
+	a.moveSnake()
+
+	if a.snake.eaten == nil || a.snake.eaten.x != 2 || a.snake.eaten.y != 1 {
+		t.Fatal("Expected food at [2 1] to have been eaten")
+	}
+
+	if a.foods[0] != first || a.foods[2] != last || len(a.foods) != 3 {
+		t.Fatal("Expected other food items to stay in place")
+	}
+}
+
+func newDoubleDuelArena(s1, s2 *snake) *arena {
+	return newArena(s1, rand.New(rand.NewSource(1)), 10, 10, withRival(s2), withFoodKinds(&foodKinds[0]))
+}
+
+func TestMoveSnakesHeadToBody(t *testing.T) {
+	s1 := newSnake(RIGHT, []coord{{x: 1, y: 5}, {x: 2, y: 5}})
+	s2 := newSnake(UP, []coord{{x: 3, y: 3}, {x: 3, y: 4}, {x: 3, y: 5}, {x: 3, y: 6}})
+	a := newDoubleDuelArena(s1, s2)
+
+	errs := a.moveSnakes()
+
+	if errs[0] == nil || errs[1] != nil {
+		t.Fatalf("Expected only the first Snake to die but got %v", errs)
+	}
+}
+
+func TestMoveSnakesHeadToHead(t *testing.T) {
+	s1 := newSnake(RIGHT, []coord{{x: 1, y: 5}, {x: 2, y: 5}})
+	s2 := newSnake(LEFT, []coord{{x: 5, y: 5}, {x: 4, y: 5}})
+	a := newDoubleDuelArena(s1, s2)
+
+	errs := a.moveSnakes()
+
+	if errs[0] == nil || errs[1] == nil {
+		t.Fatalf("Expected both Snakes to die but got %v", errs)
+	}
+}
+
+func TestMoveSnakesApart(t *testing.T) {
+	s1 := newSnake(RIGHT, []coord{{x: 1, y: 1}, {x: 2, y: 1}})
+	s2 := newSnake(LEFT, []coord{{x: 8, y: 8}, {x: 7, y: 8}})
+	a := newDoubleDuelArena(s1, s2)
+
+	if errs := a.moveSnakes(); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("Expected both Snakes to survive but got %v", errs)
+	}
+
+	if s1.head().x != 3 || s2.head().x != 6 {
+		t.Fatal("Expected both Snakes to have moved")
+	}
+}
+
+func TestQuickTurnsCannotReverseSnake(t *testing.T) {
+	a := newDoubleArena(10, 10)
+	a.snake.turn(UP)
+	a.snake.turn(LEFT)
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected Snake to survive quick turns but got %s", err)
+	}
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected Snake to survive quick turns but got %s", err)
+	}
+
+	if h := a.snake.head(); h.x != 0 || h.y != 5 {
+		t.Fatalf("Expected head at [0 5] but got %v", h)
+	}
+}
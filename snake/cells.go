@@ -0,0 +1,100 @@
+package snake
+
+// cells counts what covers each cell of the arena, snakes, obstacles and
+// food, and keeps the free cells in a Fenwick tree so the k-th free cell
+// in row order is found without scanning the arena
+type cells struct {
+	width, height int
+	// uses is how many things cover each cell, snakes may overlap
+	uses []int
+	// tree holds the number of free cells in its ranges, 1-based
+	tree []int
+	free int
+}
+
+func newCells(w, h int) *cells {
+	c := &cells{
+		width:  w,
+		height: h,
+		uses:   make([]int, w*h),
+		tree:   make([]int, w*h+1),
+		free:   w * h,
+	}
+
+	// every cell is free, so each range holds as many cells as it spans
+	for i := 1; i <= w*h; i++ {
+		c.tree[i] = i & -i
+	}
+
+	return c
+}
+
+func (c *cells) index(p coord) (int, bool) {
+	if p.x < 0 || p.y < 0 || p.x >= c.width || p.y >= c.height {
+		return 0, false
+	}
+
+	return p.y*c.width + p.x, true
+}
+
+func (c *cells) update(i, delta int) {
+	c.free += delta
+	for i++; i < len(c.tree); i += i & -i {
+		c.tree[i] += delta
+	}
+}
+
+// occupy covers the cell, cells outside the arena are ignored
+func (c *cells) occupy(p coord) {
+	i, ok := c.index(p)
+	if !ok {
+		return
+	}
+
+	c.uses[i]++
+	if c.uses[i] == 1 {
+		c.update(i, -1)
+	}
+}
+
+// release uncovers the cell once, cells that are not covered are ignored
+func (c *cells) release(p coord) {
+	i, ok := c.index(p)
+	if !ok || c.uses[i] == 0 {
+		return
+	}
+
+	c.uses[i]--
+	if c.uses[i] == 0 {
+		c.update(i, 1)
+	}
+}
+
+func (c *cells) isFree(p coord) bool {
+	i, ok := c.index(p)
+	return ok && c.uses[i] == 0
+}
+
+// nth returns the free cell with k free cells before it in row order,
+// k must be below the number of free cells
+func (c *cells) nth(k int) coord {
+	pos := 0
+
+	for step := highestBit(len(c.tree) - 1); step > 0; step >>= 1 {
+		if next := pos + step; next < len(c.tree) && c.tree[next] <= k {
+			pos = next
+			k -= c.tree[next]
+		}
+	}
+
+	return coord{x: pos % c.width, y: pos / c.width}
+}
+
+func highestBit(n int) int {
+	b := 1
+	for b<<1 <= n {
+		b <<= 1
+	}
+
+	return b
+}
@@ -0,0 +1,115 @@
+package snake
+
+import "testing"
+
+func TestCellsFindsNthFreeCellInRowOrder(t *testing.T) {
+	c := newCells(3, 2)
+	c.occupy(coord{x: 0, y: 0})
+	c.occupy(coord{x: 2, y: 0})
+	c.occupy(coord{x: 2, y: 0})
+
+	want := []coord{{x: 1, y: 0}, {x: 0, y: 1}, {x: 1, y: 1}, {x: 2, y: 1}}
+	if c.free != len(want) {
+		t.Fatalf("Expected %d free cells but got %d", len(want), c.free)
+	}
+
+	for k, w := range want {
+		if got := c.nth(k); got != w {
+			t.Fatalf("Expected free cell %d to be %v but got %v", k, w, got)
+		}
+	}
+
+	c.release(coord{x: 2, y: 0})
+	if c.isFree(coord{x: 2, y: 0}) {
+		t.Fatal("Expected a cell covered twice to stay covered after one release")
+	}
+
+	c.release(coord{x: 2, y: 0})
+	c.release(coord{x: 2, y: 0})
+	c.occupy(coord{x: -1, y: 5})
+
+	if c.free != 5 || c.nth(1) != (coord{x: 2, y: 0}) {
+		t.Fatalf("Expected 5 free cells with [2 0] second but got %d and %v", c.free, c.nth(1))
+	}
+}
+
+func TestPlaceFoodOnLastFreeCell(t *testing.T) {
+	a := newDoubleArena(5, 2)
+	a.foods = nil
+
+	var obstacles []coord
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 5; y++ {
+			if c := (coord{x: x, y: y}); !a.snake.isOnPosition(c) && c != (coord{x: 0, y: 3}) {
+				obstacles = append(obstacles, c)
+			}
+		}
+	}
+
+	a.obstacles = obstacles
+	a.track()
+
+	if f := a.placeFood(); f == nil || f.x != 0 || f.y != 3 {
+		t.Fatalf("Expected food on the last free cell [0 3] but got %v", f)
+	}
+
+	if f := a.placeFood(); f != nil {
+		t.Fatalf("Expected no food without a free cell but got %v", f)
+	}
+}
+
+func TestEngineBoardCleared(t *testing.T) {
+	p := newDoubleParameters()
+	p.Width = 4
+	p.Height = 1
+	p.StartX = 0
+	p.StartY = 0
+	p.SnakeLength = 3
+	p.Wrap = true
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the food is on the last free cell, the tail leaves room for the next one
+	st, _, done := e.Step(NOOP)
+	if done || len(st.Foods) != 1 || st.Foods[0].X != 0 {
+		t.Fatalf("Expected new food where the tail was but got %v", st.Foods)
+	}
+
+	st, _, done = e.Step(NOOP)
+	if !done || !st.BoardCleared || st.DeathCause != "" {
+		t.Fatalf("Expected the board to be cleared but got %+v", st)
+	}
+
+	g := &Game{Engine: e}
+	if g.outcome() != "BOARD CLEARED" {
+		t.Fatalf("Expected game to be won but got %q", g.outcome())
+	}
+}
+
+func TestGrowingSnakeDoesNotClearTheBoard(t *testing.T) {
+	a := newDoubleArena(5, 2)
+	a.snake.length = 10
+	a.foods = nil
+	a.track()
+
+	if a.isCleared() {
+		t.Fatal("Expected a snake that has yet to grow into the free cells not to clear the board")
+	}
+
+	a.obstacles = []coord{{x: 0, y: 0}, {x: 0, y: 0}, {x: 0, y: 1}, {x: 0, y: 2}, {x: 0, y: 3}}
+	a.track()
+
+	if a.isCleared() {
+		t.Fatal("Expected an obstacle given twice to count once")
+	}
+
+	a.obstacles = append(a.obstacles, coord{x: 0, y: 4})
+	a.track()
+
+	if !a.isCleared() {
+		t.Fatal("Expected the board to be cleared without a free cell or food")
+	}
+}
@@ -3,3 +3,18 @@ package snake
 type coord struct {
 	x, y int
 }
+
+func (c coord) next(d direction) coord {
+	switch d {
+	case RIGHT:
+		c.x++
+	case LEFT:
+		c.x--
+	case UP:
+		c.y++
+	case DOWN:
+		c.y--
+	}
+
+	return c
+}
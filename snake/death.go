@@ -0,0 +1,88 @@
+package snake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeathCause tells what killed a snake
+type DeathCause int
+
+// Death causes, NoDeath is a snake still alive
+const (
+	NoDeath DeathCause = iota
+	DeathWall
+	DeathSelf
+	DeathSnake
+	DeathObstacle
+	DeathStarvation
+	DeathTimeout
+)
+
+func (c DeathCause) String() string {
+	switch c {
+	case DeathWall:
+		return "wall"
+	case DeathSelf:
+		return "self"
+	case DeathSnake:
+		return "snake"
+	case DeathObstacle:
+		return "obstacle"
+	case DeathStarvation:
+		return "starvation"
+	case DeathTimeout:
+		return "timeout"
+	default:
+		return ""
+	}
+}
+
+func parseDeathCause(s string) (DeathCause, error) {
+	for c := NoDeath; c <= DeathTimeout; c++ {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+
+	return NoDeath, fmt.Errorf("unknown death cause %q", s)
+}
+
+// describe returns the cause as shown on the game-over screen
+func (c DeathCause) describe() string {
+	switch c {
+	case DeathWall:
+		return "hit the wall"
+	case DeathSelf:
+		return "bit itself"
+	case DeathSnake:
+		return "hit the other snake"
+	case DeathObstacle:
+		return "hit an obstacle"
+	case DeathStarvation:
+		return "starved"
+	case DeathTimeout:
+		return "ran out of time"
+	default:
+		return "is alive"
+	}
+}
+
+// DeathError is returned when a snake dies
+type DeathError struct {
+	Cause DeathCause
+}
+
+func (e *DeathError) Error() string {
+	return "Died, " + e.Cause.describe()
+}
+
+// deathCause returns the cause of the death the error reports, NoDeath for nil
+func deathCause(err error) DeathCause {
+	var d *DeathError
+	if errors.As(err, &d) {
+		return d.Cause
+	}
+
+	return NoDeath
+}
@@ -0,0 +1,428 @@
+package snake
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/imega/snake-game/state"
+)
+
+// Action is applied to the snake right before a tick
+type Action int
+
+// Engine actions, NOOP keeps the current direction
+const (
+	NOOP Action = iota
+	MoveRight
+	MoveLeft
+	MoveUp
+	MoveDown
+)
+
+func (a Action) direction() direction {
+	switch a {
+	case MoveRight:
+		return RIGHT
+	case MoveLeft:
+		return LEFT
+	case MoveUp:
+		return UP
+	case MoveDown:
+		return DOWN
+	default:
+		return 0
+	}
+}
+
+// Engine runs the arena and snake rules without any terminal attached
+type Engine struct {
+	arena      *arena
+	params     state.Parameters
+	src        *countingSource
+	rnd        *rand.Rand
+	score      int
+	rivalScore int
+	winner     int
+	pace       int
+	speed      int
+	isOver     bool
+	deaths     [2]DeathCause
+	reached    bool
+	cleared    bool
+	tallies    [2]map[string]int
+	fitness    [2]int
+	elapsed    int
+	seed       int64
+	ticks      int
+	turns      []Turn
+	speeds     []SpeedChange
+	directions []direction
+}
+
+// NewEngine creates new Engine object with a game already started,
+// the arena size and the starting snake are taken from the parameters
+func NewEngine(p state.Parameters) (*Engine, error) {
+	if err := validateParameters(p); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{params: p}
+	e.Reset(time.Now().UnixNano())
+
+	return e, nil
+}
+
+func validateParameters(p state.Parameters) error {
+	if p.Width < 1 || p.Height < 1 {
+		return fmt.Errorf("invalid arena size %dx%d", p.Width, p.Height)
+	}
+
+	if p.FoodCount < 0 {
+		return fmt.Errorf("invalid food count %d", p.FoodCount)
+	}
+
+	if p.FoodLifetime < 0 {
+		return fmt.Errorf("invalid food lifetime %d", p.FoodLifetime)
+	}
+
+	seen := make(map[state.Coord]bool, len(p.Obstacles))
+	for _, o := range p.Obstacles {
+		if seen[o] {
+			return fmt.Errorf("obstacle at %d,%d is given twice", o.X, o.Y)
+		}
+
+		seen[o] = true
+	}
+
+	foods := 1
+	if p.FoodCount > 0 {
+		foods = p.FoodCount
+	}
+
+	snakes := p.SnakeLength
+	if p.TwoPlayers {
+		snakes *= 2
+	}
+
+	if p.SnakeLength < 1 || snakes+foods > p.Width*p.Height-len(p.Obstacles) {
+		return fmt.Errorf(
+			"invalid snake length %d for a %dx%d arena with %d obstacles and %d food",
+			p.SnakeLength, p.Width, p.Height, len(p.Obstacles), foods,
+		)
+	}
+
+	if _, err := lookupFoodKinds(p.FoodKinds); err != nil {
+		return err
+	}
+
+	if err := validateMode(p); err != nil {
+		return err
+	}
+
+	if err := validateScoring(p); err != nil {
+		return err
+	}
+
+	d, err := parseDirection(p.StartDirection)
+	if err != nil {
+		return fmt.Errorf("invalid start direction, %s", err)
+	}
+
+	tail := coord{x: p.StartX, y: p.StartY}
+	head := tail
+	for i := 1; i < p.SnakeLength; i++ {
+		head = head.next(d)
+	}
+
+	for _, c := range []coord{tail, head} {
+		if c.x < 0 || c.y < 0 || c.x >= p.Width || c.y >= p.Height {
+			return fmt.Errorf(
+				"snake of length %d at %d,%d heading %s does not fit in a %dx%d arena",
+				p.SnakeLength, p.StartX, p.StartY, p.StartDirection, p.Width, p.Height,
+			)
+		}
+	}
+
+	players := []*snake{initialSnake(p)}
+	if p.TwoPlayers {
+		players = append(players, initialSnake(rivalParameters(p)))
+	}
+
+	for _, o := range p.Obstacles {
+		if o.X < 0 || o.Y < 0 || o.X >= p.Width || o.Y >= p.Height {
+			return fmt.Errorf("obstacle at %d,%d is outside the %dx%d arena", o.X, o.Y, p.Width, p.Height)
+		}
+
+		for _, s := range players {
+			if s.isOnPosition(coord{x: o.X, y: o.Y}) {
+				return fmt.Errorf("obstacle at %d,%d is on a starting snake", o.X, o.Y)
+			}
+		}
+	}
+
+	if p.TwoPlayers {
+		for _, c := range players[1].body {
+			if players[0].isOnPosition(c) {
+				return fmt.Errorf("second snake at %d,%d overlaps the first one", c.x, c.y)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reset starts a new game, the seed drives the food placement
+func (e *Engine) Reset(seed int64) state.SnakeGame {
+	e.start(seed)
+
+	return e.State()
+}
+
+// Step applies the action, advances the game by one tick and returns
+// the new state, the points earned during the tick and whether the game is over
+func (e *Engine) Step(a Action) (state.SnakeGame, int, bool) {
+	if e.isOver {
+		return e.State(), 0, true
+	}
+
+	if d := a.direction(); d != 0 {
+		e.arena.snake.turn(d)
+	}
+
+	errs := e.arena.moveSnakes()
+	e.record()
+	e.ticks++
+	e.clock()
+	e.starve(errs)
+
+	reward := e.points(0, e.arena.snake, e.eat(e.arena.snake), e.score)
+	e.addPoints(reward)
+
+	if e.arena.rival != nil {
+		e.rivalScore += e.points(1, e.arena.rival, e.eat(e.arena.rival), e.rivalScore)
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			e.deaths[i] = deathCause(err)
+			e.end()
+		}
+	}
+
+	if e.isOver && len(errs) == 2 {
+		switch {
+		case errs[0] == nil:
+			e.winner = 1
+		case errs[1] == nil:
+			e.winner = 2
+		}
+	}
+
+	if !e.isOver && e.arena.isCleared() {
+		e.cleared = true
+		e.end()
+		e.decideByScore()
+	}
+
+	if !e.isOver {
+		e.applyMode()
+	}
+
+	return e.State(), reward, e.isOver
+}
+
+// State returns a snapshot of the current game
+func (e *Engine) State() state.SnakeGame {
+	obstacles := make([]state.Coord, 0, len(e.arena.obstacles))
+	for _, o := range e.arena.obstacles {
+		obstacles = append(obstacles, state.Coord{
+			X: o.x,
+			Y: o.y,
+		})
+	}
+
+	foods := make([]state.Food, 0, len(e.arena.foods))
+	for _, f := range e.arena.foods {
+		foods = append(foods, state.Food{
+			X:      f.x,
+			Y:      f.y,
+			Points: f.points,
+			Kind:   f.kind.name,
+			Growth: f.kind.growth,
+			Effect: f.kind.effect.String(),
+			Power:  f.kind.power.String(),
+			TTL:    f.ttl,
+		})
+	}
+
+	st := state.SnakeGame{
+		Score:         e.score,
+		IsOver:        e.isOver,
+		DeathCause:    e.deaths[0].String(),
+		Mode:          gameMode(e.params),
+		TimeLeft:      e.timeLeft(),
+		TargetReached: e.reached,
+		BoardCleared:  e.cleared,
+		Fitness:       e.fitness[0],
+		ScoreParts:    e.scoreParts(0),
+		Arena: state.Arena{
+			Width:     e.arena.width,
+			Height:    e.arena.height,
+			Wrap:      e.arena.wrap,
+			Obstacles: obstacles,
+		},
+		Foods: foods,
+		Snake: snakeState(e.arena.snake),
+	}
+
+	if e.arena.rival != nil {
+		r := snakeState(e.arena.rival)
+		st.Rival = &r
+		st.RivalScore = e.rivalScore
+		st.RivalDeathCause = e.deaths[1].String()
+		st.Winner = e.winner
+	}
+
+	return st
+}
+
+// RivalState returns the snapshot as seen by the second snake, with
+// the snakes and their scores swapped
+func (e *Engine) RivalState() state.SnakeGame {
+	st := e.State()
+	if st.Rival == nil {
+		return st
+	}
+
+	s := st.Snake
+	st.Snake, st.Rival = *st.Rival, &s
+	st.Score, st.RivalScore = st.RivalScore, st.Score
+	st.DeathCause, st.RivalDeathCause = st.RivalDeathCause, st.DeathCause
+	st.Fitness, st.ScoreParts = e.fitness[1], e.scoreParts(1)
+
+	switch st.Winner {
+	case 1:
+		st.Winner = 2
+	case 2:
+		st.Winner = 1
+	}
+
+	return st
+}
+
+func snakeState(s *snake) state.Snake {
+	body := make([]state.Coord, 0, len(s.body))
+	for _, v := range s.body {
+		body = append(body, state.Coord{
+			X: v.x,
+			Y: v.y,
+		})
+	}
+
+	return state.Snake{
+		Head: state.Coord{
+			X: s.head().x,
+			Y: s.head().y,
+		},
+		Body:   body,
+		Steps:  s.steps,
+		Powers: s.activePowers(),
+	}
+}
+
+// eat applies the effect of the food the snake has just eaten
+// and returns the points it is worth
+func (e *Engine) eat(s *snake) int {
+	f := s.eaten
+	if f == nil {
+		return 0
+	}
+
+	points := f.points
+	if s.has(multiplier) {
+		points *= scoreMultiplier
+	}
+
+	s.give(f.kind.power, f.kind.duration)
+
+	switch f.kind.effect {
+	case speedUp:
+		e.pace += paceStep
+	case slowDown:
+		e.pace -= paceStep
+	case bonus:
+		points += bonusPerSegment * len(s.body)
+	}
+
+	return points
+}
+
+// starve kills the snakes that went too long without eating and every
+// snake once the game has run out of ticks
+func (e *Engine) starve(errs []error) {
+	for i, s := range e.arena.players() {
+		if errs[i] == nil && e.params.MaxSnakeSteps > 0 && s.steps > e.params.MaxSnakeSteps {
+			errs[i] = s.die(DeathStarvation)
+		}
+	}
+
+	if e.params.MaxTicks == 0 || e.ticks < e.params.MaxTicks {
+		return
+	}
+
+	for i, s := range e.arena.players() {
+		if errs[i] == nil {
+			errs[i] = s.die(DeathTimeout)
+		}
+	}
+}
+
+func (e *Engine) end() {
+	e.isOver = true
+}
+
+// Retry starts the next game with a seed drawn from the current one and
+// returns its state
+func (e *Engine) Retry() state.SnakeGame {
+	e.retry()
+
+	return e.State()
+}
+
+// retry starts the next game with a seed drawn from the current one,
+// so that every game can be replayed on its own
+func (e *Engine) retry() {
+	e.start(e.rnd.Int63())
+}
+
+func (e *Engine) start(seed int64) {
+	e.seed = seed
+	e.src = newCountingSource(seed)
+	e.rnd = rand.New(e.src)
+	e.arena = initialArena(e.params, e.rnd)
+	e.score = initialScore()
+	e.rivalScore = initialScore()
+	e.winner = 0
+	e.pace = 0
+	e.speed = e.params.Speed
+	e.isOver = false
+	e.deaths = [2]DeathCause{}
+	e.reached = false
+	e.cleared = false
+	e.tallies = [2]map[string]int{}
+	e.fitness = [2]int{}
+	e.elapsed = 0
+	e.ticks = 0
+	e.turns = nil
+	e.speeds = nil
+	e.directions = e.directions[:0]
+
+	for _, s := range e.arena.players() {
+		e.directions = append(e.directions, s.direction)
+	}
+}
+
+func (e *Engine) addPoints(p int) {
+	e.score += p
+}
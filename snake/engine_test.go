@@ -0,0 +1,294 @@
+package snake
+
+import (
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func newDoubleParameters() state.Parameters {
+	return state.Parameters{
+		Width:          50,
+		Height:         20,
+		SnakeLength:    4,
+		StartX:         1,
+		StartY:         1,
+		StartDirection: "right",
+		FoodKinds:      []string{"fruit"},
+	}
+}
+
+func newDoubleEngine(t *testing.T) *Engine {
+	e, err := NewEngine(newDoubleParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestEngineResetIsDeterministic(t *testing.T) {
+	e1 := newDoubleEngine(t)
+	e2 := newDoubleEngine(t)
+
+	s1 := e1.Reset(42)
+	s2 := e2.Reset(42)
+
+	if s1.Foods[0] != s2.Foods[0] {
+		t.Fatalf("Expected same food for same seed but got %v and %v", s1.Foods, s2.Foods)
+	}
+}
+
+func TestEngineStepMovesSnake(t *testing.T) {
+	e := newDoubleEngine(t)
+	h := e.State().Snake.Head
+
+	st, _, done := e.Step(MoveUp)
+
+	if done {
+		t.Fatal("Expected game not to be over")
+	}
+
+	if st.Snake.Head.X != h.X || st.Snake.Head.Y != h.Y+1 {
+		t.Fatalf("Expected head to have moved up from %v but got %v", h, st.Snake.Head)
+	}
+}
+
+func TestEngineStepRewardsEatenFood(t *testing.T) {
+	e := newDoubleEngine(t)
+	e.arena.findFood = func(*arena, coord) int {
+		return 0
+	}
+
+	st, reward, _ := e.Step(NOOP)
+
+	if reward != 10 || st.Score != 10 {
+		t.Fatalf("Expected reward and score to be 10 but got %d and %d", reward, st.Score)
+	}
+}
+
+func TestEngineStepEndsGameWhenSnakeDies(t *testing.T) {
+	e := newDoubleEngine(t)
+
+	_, _, done := e.Step(MoveDown)
+
+	for i := 0; i < e.arena.height && !done; i++ {
+		_, _, done = e.Step(NOOP)
+	}
+
+	if st := e.State(); !done || !st.IsOver || st.DeathCause != "wall" {
+		t.Fatalf("Expected game to be over after hitting the wall but got %q", st.DeathCause)
+	}
+
+	if _, reward, done := e.Step(MoveUp); reward != 0 || !done {
+		t.Fatal("Expected finished game not to advance")
+	}
+}
+
+func TestEngineSnakeStarves(t *testing.T) {
+	p := newDoubleParameters()
+	p.MaxSnakeSteps = 3
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.arena.findFood = func(*arena, coord) int {
+		return -1
+	}
+
+	var (
+		st   state.SnakeGame
+		done bool
+		tick int
+	)
+
+	for !done && tick < 10 {
+		st, _, done = e.Step(NOOP)
+		tick++
+	}
+
+	if tick != 4 || st.DeathCause != "starvation" {
+		t.Fatalf("Expected snake to starve on tick 4 but got %q on tick %d", st.DeathCause, tick)
+	}
+}
+
+func TestEngineRunsOutOfTicks(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+	p.MaxTicks = 2
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Step(NOOP)
+	st, _, done := e.Step(NOOP)
+
+	if !done || st.DeathCause != "timeout" || st.RivalDeathCause != "timeout" || st.Winner != 0 {
+		t.Fatalf("Expected both snakes to run out of time but got %+v", st)
+	}
+}
+
+func TestEngineStartsWithConfiguredSnake(t *testing.T) {
+	p := newDoubleParameters()
+	p.SnakeLength = 3
+	p.StartX = 5
+	p.StartY = 5
+	p.StartDirection = "down"
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := e.State()
+	if len(st.Snake.Body) != 3 || st.Snake.Head != (state.Coord{X: 5, Y: 3}) {
+		t.Fatalf("Expected snake of length 3 with head at [5 3] but got %v", st.Snake.Body)
+	}
+
+	if e.arena.width != 50 || e.arena.height != 20 {
+		t.Fatalf("Expected 50x20 arena but got %dx%d", e.arena.width, e.arena.height)
+	}
+}
+
+func TestNewEngineRejectsInvalidParameters(t *testing.T) {
+	cases := map[string]func(*state.Parameters){
+		"empty arena":       func(p *state.Parameters) { p.Width = 0 },
+		"no snake":          func(p *state.Parameters) { p.SnakeLength = 0 },
+		"unknown direction": func(p *state.Parameters) { p.StartDirection = "north" },
+		"tail outside":      func(p *state.Parameters) { p.StartX = -1 },
+		"head outside":      func(p *state.Parameters) { p.StartX = 48 },
+		"obstacle outside":  func(p *state.Parameters) { p.Obstacles = []state.Coord{{X: 50, Y: 0}} },
+		"obstacle on snake": func(p *state.Parameters) { p.Obstacles = []state.Coord{{X: 2, Y: 1}} },
+		"obstacle twice":    func(p *state.Parameters) { p.Obstacles = []state.Coord{{X: 3, Y: 3}, {X: 3, Y: 3}} },
+		"unknown food kind": func(p *state.Parameters) { p.FoodKinds = []string{"brick"} },
+		"snakes overlap":    func(p *state.Parameters) { p.TwoPlayers, p.Width, p.Height, p.StartY = true, 7, 3, 1 },
+		"negative lifetime": func(p *state.Parameters) { p.FoodLifetime = -1 },
+		"negative food":     func(p *state.Parameters) { p.FoodCount = -1 },
+		"too much food":     func(p *state.Parameters) { p.FoodCount = 50*20 - 3 },
+		"no room for food":  func(p *state.Parameters) { p.Width, p.Height, p.StartX, p.StartY = 4, 1, 0, 0 },
+	}
+
+	for name, f := range cases {
+		p := newDoubleParameters()
+		f(&p)
+
+		if _, err := NewEngine(p); err == nil {
+			t.Fatalf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestEngineStateExportsObstacles(t *testing.T) {
+	p := newDoubleParameters()
+	p.Obstacles = []state.Coord{{X: 10, Y: 10}, {X: 11, Y: 10}}
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o := e.State().Arena.Obstacles; len(o) != 2 || o[1] != p.Obstacles[1] {
+		t.Fatalf("Expected obstacles %v but got %v", p.Obstacles, o)
+	}
+}
+
+func TestEngineStepAppliesFoodEffects(t *testing.T) {
+	e := newDoubleEngine(t)
+	e.arena.findFood = func(*arena, coord) int {
+		return 0
+	}
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, findFoodKind("taco"), 0, 0)
+	e.Step(NOOP)
+
+	if e.pace != paceStep {
+		t.Fatalf("Expected taco to speed the snake up by %d but got %d", paceStep, e.pace)
+	}
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, findFoodKind("cake"), 0, 0)
+	_, reward, _ := e.Step(NOOP)
+
+	if want := 30 + bonusPerSegment*len(e.arena.snake.body); reward != want {
+		t.Fatalf("Expected cake to be worth %d but got %d", want, reward)
+	}
+}
+
+func TestEngineStateReportsFoodKind(t *testing.T) {
+	p := newDoubleParameters()
+	p.FoodKinds = []string{"ice"}
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := e.State().Foods[0]
+	if f.Kind != "ice" || f.Effect != "slow down" || f.Points != 5 || f.Growth != 1 || f.TTL != 80 {
+		t.Fatalf("Unexpected food %+v", f)
+	}
+}
+
+func TestEngineTwoPlayers(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := e.State()
+	if st.Rival == nil || st.Rival.Head != (state.Coord{X: 45, Y: 18}) {
+		t.Fatalf("Expected second snake head at [45 18] but got %v", st.Rival)
+	}
+
+	e.arena.findFood = func(a *arena, c coord) int {
+		if c == a.rival.head() {
+			return 0
+		}
+
+		return -1
+	}
+
+	st, reward, _ := e.Step(NOOP)
+	if reward != 0 || st.Score != 0 || st.RivalScore != 10 {
+		t.Fatalf("Expected only the second snake to score but got %d and %d", st.Score, st.RivalScore)
+	}
+
+	st, _, done := e.Step(MoveDown)
+	for !done {
+		st, _, done = e.Step(NOOP)
+	}
+
+	if st.Winner != 2 {
+		t.Fatalf("Expected second snake to win but got %d", st.Winner)
+	}
+}
+
+func TestEngineRivalStateSwapsSnakes(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.rivalScore = 20
+	e.winner = 1
+	e.deaths = [2]DeathCause{DeathSnake, NoDeath}
+
+	st, rst := e.State(), e.RivalState()
+
+	if rst.Snake.Head != st.Rival.Head || rst.Rival.Head != st.Snake.Head {
+		t.Fatal("Expected snakes to be swapped")
+	}
+
+	if rst.Score != 20 || rst.RivalScore != 0 || rst.Winner != 2 || rst.RivalDeathCause != "snake" {
+		t.Fatalf("Expected scores and winner to be swapped but got %+v", rst)
+	}
+}
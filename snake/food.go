@@ -1,53 +1,298 @@
 package snake
 
 import (
+	"fmt"
 	"math/rand"
 	"os"
 	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+type foodEffect int
+
+// Food effects applied when the food is eaten
+const (
+	noEffect foodEffect = iota
+	shrink
+	speedUp
+	slowDown
+	bonus
+)
+
+func (e foodEffect) String() string {
+	switch e {
+	case shrink:
+		return "shrink"
+	case speedUp:
+		return "speed up"
+	case slowDown:
+		return "slow down"
+	case bonus:
+		return "bonus"
+	default:
+		return ""
+	}
+}
+
+const (
+	// paceStep is how many milliseconds a speed effect takes off or adds to a tick
+	paceStep = 10
+	// bonusPerSegment is how many extra points a bonus food gives per snake segment
+	bonusPerSegment = 5
 )
 
+type foodKind struct {
+	name     string
+	points   int
+	growth   int
+	weight   int
+	lifetime int
+	effect   foodEffect
+	power    powerUp
+	duration int
+	glyph    rune
+	emojis   []rune
+	color    termbox.Attribute
+}
+
+var foodKinds = []foodKind{
+	{
+		name:   "fruit",
+		points: 10,
+		growth: 1,
+		weight: 80,
+		glyph:  '@',
+		emojis: []rune{
+			'🍒',
+			'🍍',
+			'🍑',
+			'🍇',
+			'🍏',
+			'🍌',
+			'🍫',
+			'🍭',
+			'🍕',
+			'🍩',
+			'🍗',
+			'🍖',
+			'🍬',
+			'🍤',
+			'🍪',
+		},
+		color: termbox.ColorDefault,
+	},
+	{
+		name:     "cake",
+		points:   30,
+		growth:   1,
+		weight:   5,
+		lifetime: 60,
+		effect:   bonus,
+		glyph:    '$',
+		emojis:   []rune{'🎂'},
+		color:    termbox.ColorYellow,
+	},
+	{
+		name:     "taco",
+		points:   20,
+		growth:   1,
+		weight:   5,
+		lifetime: 80,
+		effect:   speedUp,
+		glyph:    '>',
+		emojis:   []rune{'🌮'},
+		color:    termbox.ColorRed,
+	},
+	{
+		name:     "ice",
+		points:   5,
+		growth:   1,
+		weight:   5,
+		lifetime: 80,
+		effect:   slowDown,
+		glyph:    '<',
+		emojis:   []rune{'🍧'},
+		color:    termbox.ColorCyan,
+	},
+	{
+		name:     "mushroom",
+		points:   5,
+		growth:   -2,
+		weight:   5,
+		lifetime: 100,
+		effect:   shrink,
+		glyph:    '-',
+		emojis:   []rune{'🍄'},
+		color:    termbox.ColorMagenta,
+	},
+	{
+		name:     "lightning",
+		weight:   2,
+		lifetime: 60,
+		power:    boost,
+		duration: 40,
+		glyph:    '!',
+		emojis:   []rune{'⚡'},
+		color:    termbox.ColorYellow,
+	},
+	{
+		name:     "snail",
+		weight:   2,
+		lifetime: 60,
+		power:    slowMotion,
+		duration: 40,
+		glyph:    '~',
+		emojis:   []rune{'🐌'},
+		color:    termbox.ColorGreen,
+	},
+	{
+		name:     "star",
+		weight:   1,
+		lifetime: 40,
+		power:    invincible,
+		duration: 30,
+		glyph:    '*',
+		emojis:   []rune{'⭐'},
+		color:    termbox.ColorYellow,
+	},
+	{
+		name:     "ghost",
+		weight:   2,
+		lifetime: 60,
+		power:    ghost,
+		duration: 40,
+		glyph:    '?',
+		emojis:   []rune{'👻'},
+		color:    termbox.ColorWhite,
+	},
+	{
+		name:     "gem",
+		weight:   2,
+		lifetime: 60,
+		power:    multiplier,
+		duration: 50,
+		glyph:    'x',
+		emojis:   []rune{'💎'},
+		color:    termbox.ColorCyan,
+	},
+}
+
+// FoodKinds returns the names of the food kinds in the catalogue
+func FoodKinds() []string {
+	names := make([]string, 0, len(foodKinds))
+	for _, k := range foodKinds {
+		names = append(names, k.name)
+	}
+
+	return names
+}
+
+// lookupFoodKinds returns the catalogue entries with the given names,
+// every food but the power-ups when no name is given, power-ups only
+// spawn when they are named
+func lookupFoodKinds(names []string) ([]*foodKind, error) {
+	kinds := make([]*foodKind, 0, len(foodKinds))
+
+	if len(names) == 0 {
+		for i := range foodKinds {
+			if foodKinds[i].power == noPower {
+				kinds = append(kinds, &foodKinds[i])
+			}
+		}
+
+		return kinds, nil
+	}
+
+	for _, n := range names {
+		k := findFoodKind(n)
+		if k == nil {
+			return nil, fmt.Errorf("unknown food kind %q", n)
+		}
+
+		kinds = append(kinds, k)
+	}
+
+	return kinds, nil
+}
+
+func findFoodKind(name string) *foodKind {
+	for i := range foodKinds {
+		if foodKinds[i].name == name {
+			return &foodKinds[i]
+		}
+	}
+
+	return nil
+}
+
+func randomFoodKind(r *rand.Rand, kinds []*foodKind) *foodKind {
+	var total int
+	for _, k := range kinds {
+		total += k.weight
+	}
+
+	n := r.Intn(total)
+	for _, k := range kinds {
+		if n < k.weight {
+			return k
+		}
+
+		n -= k.weight
+	}
+
+	return kinds[len(kinds)-1]
+}
+
 type food struct {
+	kind         *foodKind
 	emoji        rune
+	ttl          int
 	points, x, y int
+	// fresh marks food put on the board by a meal during the tick, its
+	// lifetime starts on the next tick
+	fresh bool
+}
+
+func newFood(r *rand.Rand, x, y int) *food {
+	return newFoodOfKind(r, &foodKinds[0], x, y)
 }
 
-func newFood(x, y int) *food {
+func newFoodOfKind(r *rand.Rand, k *foodKind, x, y int) *food {
 	return &food{
-		points: 10,
-		emoji:  getFoodEmoji(),
+		kind:   k,
+		ttl:    k.lifetime,
+		points: k.points,
+		emoji:  getFoodEmoji(r, k),
 		x:      x,
 		y:      y,
 	}
 }
 
-func getFoodEmoji() rune {
-	if hasUnicodeSupport() {
-		return randomFoodEmoji()
+// tick counts down the lifetime and tells whether the food has expired,
+// food without a lifetime never expires
+func (f *food) tick() bool {
+	if f.ttl == 0 {
+		return false
 	}
 
-	return '@'
+	f.ttl--
+
+	return f.ttl == 0
 }
 
-func randomFoodEmoji() rune {
-	f := []rune{
-		'🍒',
-		'🍍',
-		'🍑',
-		'🍇',
-		'🍏',
-		'🍌',
-		'🍫',
-		'🍭',
-		'🍕',
-		'🍩',
-		'🍗',
-		'🍖',
-		'🍬',
-		'🍤',
-		'🍪',
+func getFoodEmoji(r *rand.Rand, k *foodKind) rune {
+	e := randomFoodEmoji(r, k)
+
+	if hasUnicodeSupport() {
+		return e
 	}
 
-	return f[rand.Intn(len(f))]
+	return k.glyph
+}
+
+func randomFoodEmoji(r *rand.Rand, k *foodKind) rune {
+	return k.emojis[r.Intn(len(k.emojis))]
 }
 
 func hasUnicodeSupport() bool {
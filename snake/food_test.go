@@ -1,12 +1,13 @@
 package snake
 
 import (
+	"math/rand"
 	"os"
 	"testing"
 )
 
 func TestFoodDefaultPoints(t *testing.T) {
-	f := newFood(10, 10)
+	f := newFood(rand.New(rand.NewSource(1)), 10, 10)
 
 	if f.points != 10 {
 		t.Fatalf("Expected Food default points to be 10 but got %v", f.points)
@@ -14,7 +15,7 @@ func TestFoodDefaultPoints(t *testing.T) {
 }
 
 func TestFoodEmoji(t *testing.T) {
-	f := newFood(10, 10)
+	f := newFood(rand.New(rand.NewSource(1)), 10, 10)
 
 	if string(f.emoji) == "" {
 		t.Fatal("Food emoji not expected to be blank")
@@ -24,9 +25,95 @@ func TestFoodEmoji(t *testing.T) {
 func TestFoodFallback(t *testing.T) {
 	os.Setenv("LANG", "c")
 
-	f := newFood(10, 10)
+	f := newFood(rand.New(rand.NewSource(1)), 10, 10)
 
 	if string(f.emoji) != "@" {
 		t.Fatal("Food emoji expected to be '@'")
 	}
 }
+
+func TestFoodEmojiDoesNotDependOnUnicodeSupport(t *testing.T) {
+	r1 := rand.New(rand.NewSource(1))
+	r2 := rand.New(rand.NewSource(1))
+
+	os.Setenv("LANG", "en_US.UTF-8")
+	getFoodEmoji(r1, &foodKinds[0])
+
+	os.Setenv("LANG", "c")
+	getFoodEmoji(r2, &foodKinds[0])
+
+	if r1.Int63() != r2.Int63() {
+		t.Fatal("Expected food emoji to consume the same randomness with or without unicode support")
+	}
+}
+
+func TestFoodKindFallbackGlyph(t *testing.T) {
+	os.Setenv("LANG", "c")
+
+	f := newFoodOfKind(rand.New(rand.NewSource(1)), findFoodKind("cake"), 10, 10)
+
+	if f.emoji != '$' || f.points != 30 {
+		t.Fatalf("Expected cake to be '$' worth 30 points but got %q worth %d", f.emoji, f.points)
+	}
+}
+
+func TestLookupFoodKinds(t *testing.T) {
+	all, err := lookupFoodKinds(nil)
+	if err != nil || len(all) != 5 {
+		t.Fatalf("Expected the catalogue without power-ups but got %d kinds, %v", len(all), err)
+	}
+
+	for _, k := range all {
+		if k.power != noPower {
+			t.Fatalf("Expected power-ups to be left out by default but got %s", k.name)
+		}
+	}
+
+	if star, err := lookupFoodKinds([]string{"fruit", "star"}); err != nil || len(star) != 2 || star[1].power != invincible {
+		t.Fatalf("Expected a named power-up to spawn but got %v, %v", star, err)
+	}
+
+	some, err := lookupFoodKinds([]string{"taco", "ice"})
+	if err != nil || len(some) != 2 || some[0].effect != speedUp || some[1].effect != slowDown {
+		t.Fatalf("Expected taco and ice but got %v, %v", some, err)
+	}
+
+	if _, err := lookupFoodKinds([]string{"brick"}); err == nil {
+		t.Fatal("Expected unknown food kind to be rejected")
+	}
+}
+
+func TestRandomFoodKindFollowsWeights(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	kinds := []*foodKind{findFoodKind("fruit"), {name: "never", weight: 0}}
+
+	for i := 0; i < 100; i++ {
+		if k := randomFoodKind(r, kinds); k.name != "fruit" {
+			t.Fatalf("Expected kind without weight never to spawn but got %s", k.name)
+		}
+	}
+}
+
+func TestFoodExpiresAfterLifetime(t *testing.T) {
+	f := newFoodOfKind(rand.New(rand.NewSource(1)), findFoodKind("cake"), 10, 10)
+
+	for i := 1; i < 60; i++ {
+		if f.tick() {
+			t.Fatalf("Expected cake not to expire after %d ticks", i)
+		}
+	}
+
+	if !f.tick() || f.ttl != 0 {
+		t.Fatal("Expected cake to expire after 60 ticks")
+	}
+}
+
+func TestFoodWithoutLifetimeNeverExpires(t *testing.T) {
+	f := newFood(rand.New(rand.NewSource(1)), 10, 10)
+
+	for i := 0; i < 1000; i++ {
+		if f.tick() {
+			t.Fatal("Expected fruit never to expire")
+		}
+	}
+}
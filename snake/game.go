@@ -1,159 +1,428 @@
 package snake
 
 import (
+	"math/rand"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/imega/snake-game/state"
 	"github.com/nsf/termbox-go"
 )
 
-var (
-	pointsChan         = make(chan int)
-	KeyboardEventsChan = make(chan KeyboardEvent)
+var KeyboardEventsChan = make(chan KeyboardEvent)
+
+const (
+	// pollInterval is how often a paused game looks for a key press
+	pollInterval = 50 * time.Millisecond
+	// noticeDuration is how long a notice replaces the title
+	noticeDuration = 2 * time.Second
+	// defaultSnapshotFile is where the save and load keys keep the game
+	defaultSnapshotFile = "snake-snapshot.json"
+	// maxRequests is how many key presses wait for the game loop
+	maxRequests = 4
 )
 
 // Game type
 type Game struct {
-	arena  *arena
-	score  int
-	isOver bool
+	*Engine
+	keymap *Keymap
+	paused bool
+	step   bool
+	// title replaces the title of the game mode when set
+	title       string
+	notice      string
+	noticeUntil time.Time
+	// best is the best result of each mode in this session
+	best map[string]int
+	// highScores is the high-score table of the last game and rank its
+	// place in it
+	highScores []ScoreEntry
+	rank       int
+	// requests are the key presses that replace the game or change its
+	// speed, the game loop serves them between ticks
+	requests chan KeyboardEvent
+	// mu keeps the keyboard goroutine out of the game while the loop
+	// serves, ticks or renders it
+	mu sync.Mutex
 }
 
-func initialSnake() *snake {
-	return newSnake(RIGHT, []coord{
-		{x: 1, y: 1},
-		{x: 1, y: 2},
-		{x: 1, y: 3},
-		{x: 1, y: 4},
-	})
+func initialSnake(p state.Parameters) *snake {
+	d, _ := parseDirection(p.StartDirection)
+
+	body := make([]coord, 0, p.SnakeLength)
+	c := coord{x: p.StartX, y: p.StartY}
+
+	for i := 0; i < p.SnakeLength; i++ {
+		body = append(body, c)
+		c = c.next(d)
+	}
+
+	return newSnake(d, body)
+}
+
+// rivalParameters places the second snake point-symmetric to the first one
+func rivalParameters(p state.Parameters) state.Parameters {
+	d, _ := parseDirection(p.StartDirection)
+
+	p.StartX = p.Width - 1 - p.StartX
+	p.StartY = p.Height - 1 - p.StartY
+	p.StartDirection = d.opposite().String()
+
+	return p
 }
 
 func initialScore() int {
 	return 0
 }
 
-func initialArena() *arena {
-	return newArena(initialSnake(), pointsChan, 20, 50)
+func initialArena(p state.Parameters, r *rand.Rand) *arena {
+	obstacles := make([]coord, 0, len(p.Obstacles))
+	for _, o := range p.Obstacles {
+		obstacles = append(obstacles, coord{x: o.X, y: o.Y})
+	}
+
+	kinds, _ := lookupFoodKinds(p.FoodKinds)
+
+	var rival *snake
+	if p.TwoPlayers {
+		rival = initialSnake(rivalParameters(p))
+	}
+
+	return newArena(
+		initialSnake(p), r, p.Height, p.Width,
+		withRival(rival),
+		withObstacles(obstacles...),
+		withWrap(p.Wrap),
+		withFood(p.FoodCount, p.FoodPoints),
+		withFoodKinds(kinds...),
+		withFoodLifetime(p.FoodLifetime),
+	)
 }
 
-func (g *Game) end() {
-	g.isOver = true
+func (g *Game) moveInterval() time.Duration {
+	return time.Duration(g.interval()) * time.Millisecond
 }
 
-func (g *Game) moveInterval(speed int) time.Duration {
-	ms := 1*speed - (g.score / 10)
-	return time.Duration(ms) * time.Millisecond
+// steer turns the first snake with the first player's keys and the second
+// one with the second player's keys, which turn the first snake as well
+// when the second one is not human
+func (g *Game) steer(e KeyboardEvent) {
+	s := g.arena.snake
+	if g.arena.rival != nil && (e.Rival || (e.Second && !g.params.Versus)) {
+		s = g.arena.rival
+	}
+
+	s.turn(keyToDirection(e.Key))
 }
 
-func (g *Game) retry() {
-	g.arena = initialArena()
-	g.score = initialScore()
-	g.isOver = false
+// changeSpeed makes a tick shorter or longer by 10 milliseconds
+func changeSpeed(speed int, ch rune) int {
+	switch ch {
+	case speedUpCh:
+		speed -= 10
+	case speedDownCh:
+		speed += 10
+	}
+
+	if speed < 0 {
+		return 0
+	}
+
+	return speed
 }
 
-func (g *Game) addPoints(p int) {
-	g.score += p
+func (g *Game) togglePause() {
+	g.paused = !g.paused
+	g.step = false
 }
 
-// NewGame creates new Game object
-func NewGame() *Game {
-	return &Game{arena: initialArena(), score: initialScore()}
+// requestStep lets a paused game advance exactly one tick, it pauses a running game
+func (g *Game) requestStep() {
+	if !g.paused {
+		g.paused = true
+		return
+	}
+
+	g.step = true
 }
 
-// Start starts the game
-func (g *Game) Start(p state.Parameters, ch chan state.SnakeGame, statCh chan state.Stat) {
-	speed := p.Speed
-	if err := termbox.Init(); err != nil {
-		panic(err)
+// tick advances the game unless it is over or paused and tells whether it moved
+func (g *Game) tick() bool {
+	if g.isOver || (g.paused && !g.step) {
+		return false
 	}
-	defer termbox.Close()
 
-	go listenToKeyboard(KeyboardEventsChan)
+	g.step = false
+	g.Step(NOOP)
 
-	stat := state.Stat{}
+	if g.isOver {
+		g.keepBest()
+	}
+
+	return true
+}
 
-	if err := g.render(p, stat); err != nil {
-		panic(err)
+// outcome is the headline of the game-over screen
+func (g *Game) outcome() string {
+	switch {
+	case g.cleared:
+		return "BOARD CLEARED"
+	case g.reached:
+		return "TARGET REACHED"
+	default:
+		return "GAME OVER"
 	}
+}
 
-	go func() {
-		for s := range statCh {
-			stat = s
-		}
-	}()
+// notify shows the message in place of the title for a moment
+func (g *Game) notify(msg string) {
+	g.notice = msg
+	g.noticeUntil = time.Now().Add(noticeDuration)
+}
 
-	go func() {
-		for e := range KeyboardEventsChan {
+func (g *Game) snapshotFile() string {
+	if g.params.Snapshot != "" {
+		return g.params.Snapshot
+	}
+
+	return defaultSnapshotFile
+}
+
+// saveSnapshot writes the game in progress to the snapshot file
+func (g *Game) saveSnapshot() {
+	if g.isOver {
+		g.notify("The game is over, there is nothing to save")
+		return
+	}
+
+	if err := g.Snapshot().Save(g.snapshotFile()); err != nil {
+		g.notify(err.Error())
+		return
+	}
+
+	g.notify("Saved to " + g.snapshotFile())
+}
+
+// loadSnapshot replaces the game with the one from the snapshot file and pauses it
+func (g *Game) loadSnapshot() {
+	s, err := LoadSnapshot(g.snapshotFile())
+	if err == nil {
+		err = g.Restore(s)
+	}
+
+	if err != nil {
+		g.notify(err.Error())
+		return
+	}
+
+	g.paused = true
+	g.notify("Loaded from " + g.snapshotFile())
+}
+
+// request hands the key press to the game loop, it is dropped when the
+// loop has not served the earlier ones yet
+func (g *Game) request(e KeyboardEvent) {
+	select {
+	case g.requests <- e:
+	default:
+	}
+}
+
+// handle applies a key press read by the keyboard goroutine
+func (g *Game) handle(e KeyboardEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch e.EventType {
+	case MOVE:
+		g.steer(e)
+	case RETRY, SAVE, LOAD, SPEED:
+		g.request(e)
+	case PAUSE:
+		g.togglePause()
+	case STEP:
+		g.requestStep()
+	}
+}
+
+// serve handles the key presses waiting for the game loop, they change
+// the engine so they must not run during a tick or a render
+func (g *Game) serve() {
+	for {
+		select {
+		case e := <-g.requests:
 			switch e.EventType {
-			case MOVE:
-				d := keyToDirection(e.Key)
-				g.arena.snake.changeDirection(d)
 			case RETRY:
 				g.retry()
+			case SAVE:
+				g.saveSnapshot()
+			case LOAD:
+				g.loadSnapshot()
 			case SPEED:
-				if e.Key == termbox.KeySpace {
-					speed += 10
-				}
-				if e.Key == termbox.KeyBackspace {
-					speed -= 10
-				}
+				g.SetSpeed(changeSpeed(g.speed, e.Ch))
+			}
+		default:
+			return
+		}
+	}
+}
+
+// NewGame creates new Game object
+func NewGame(p state.Parameters) (*Game, error) {
+	e, err := NewEngine(p)
+	if err != nil {
+		return nil, err
+	}
+
+	km, err := LoadKeymap(p.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Reset(p.Seed)
+
+	return &Game{Engine: e, keymap: km, requests: make(chan KeyboardEvent, maxRequests)}, nil
+}
+
+// Drive shows the game in the terminal while run steps it, such as a
+// training run. The show function passed to run draws the game with the
+// stat and waits for the next tick, the game keys work as in Start except
+// the arrows, run steers the snake.
+func (g *Game) Drive(p state.Parameters, run func(show func(state.Stat) error) error) error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	defer termbox.Close()
+
+	go listenToKeyboard(KeyboardEventsChan, g.keymap)
+
+	go func() {
+		for e := range KeyboardEventsChan {
+			switch e.EventType {
 			case END:
 				termbox.Close()
 				os.Exit(0)
+			case MOVE:
+			default:
+				g.handle(e)
 			}
 		}
 	}()
 
+	return run(func(stat state.Stat) error {
+		return g.show(p, stat)
+	})
+}
+
+// show serves the waiting key presses, draws the game driven by Drive and
+// waits for the next tick, a paused game waits until it is resumed or stepped
+func (g *Game) show(p state.Parameters, stat state.Stat) error {
 	for {
-		select {
-		case p := <-pointsChan:
-			g.addPoints(p)
-		default:
-			if !g.isOver {
-				if err := g.arena.moveSnake(); err != nil {
-					g.end()
-				}
-			}
+		g.mu.Lock()
+		g.serve()
+		hold := g.paused && !g.step
+		g.step = false
+		err := g.render(p, stat)
+		wait := g.wait()
+		g.mu.Unlock()
 
-			var body []state.Coord
-			for _, v := range g.arena.snake.body {
-				body = append(body, state.Coord{
-					X: v.x,
-					Y: v.y,
-				})
-			}
+		if err != nil {
+			return err
+		}
 
-			if err := g.render(p, stat); err != nil {
-				panic(err)
-			}
+		time.Sleep(wait)
 
-			if !p.Human {
-				ch <- state.SnakeGame{
-					Score:  g.score,
-					IsOver: g.isOver,
-					Arena: state.Arena{
-						Width:  g.arena.width,
-						Height: g.arena.height,
-					},
-					Food: state.Coord{
-						X: g.arena.food.x,
-						Y: g.arena.food.y,
-					},
-					Snake: state.Snake{
-						Head: state.Coord{
-							X: g.arena.snake.head().x,
-							Y: g.arena.snake.head().y,
-						},
-						Body:  body,
-						Steps: g.arena.snake.steps,
-					},
-				}
-			}
+		if !hold {
+			return nil
+		}
+	}
+}
+
+// Start starts the game
+func (g *Game) Start(p state.Parameters, ch chan state.SnakeGame) error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	defer termbox.Close()
+
+	go listenToKeyboard(KeyboardEventsChan, g.keymap)
 
-			if speed > 0 {
-				time.Sleep(g.moveInterval(speed))
+	if err := g.render(p, state.Stat{}); err != nil {
+		return err
+	}
+
+	go func() {
+		for e := range KeyboardEventsChan {
+			if e.EventType == END {
+				termbox.Close()
+				os.Exit(0)
 			}
+
+			g.handle(e)
+		}
+	}()
+
+	for {
+		g.mu.Lock()
+		st, send, err := g.turn(p)
+		wait := g.wait()
+		g.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		if send {
+			ch <- st
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// turn serves the waiting key presses, advances the game and draws it,
+// it returns the state for the AI and whether the AI waits for it
+func (g *Game) turn(p state.Parameters) (state.SnakeGame, bool, error) {
+	g.serve()
+	moved := g.tick()
+
+	if moved && g.isOver {
+		g.saveScore()
+	}
+
+	if moved && g.isOver && p.Record != "" {
+		if _, err := g.Replay().Save(p.Record); err != nil {
+			return state.SnakeGame{}, false, err
 		}
 	}
+
+	if err := g.render(p, state.Stat{}); err != nil {
+		return state.SnakeGame{}, false, err
+	}
+
+	if !moved && !g.isOver {
+		return state.SnakeGame{}, false, nil
+	}
+
+	switch {
+	case p.Versus:
+		return g.RivalState(), true, nil
+	case !p.Human:
+		return g.State(), true, nil
+	}
+
+	return state.SnakeGame{}, false, nil
+}
+
+// wait is how long the game loop sleeps before the next turn
+func (g *Game) wait() time.Duration {
+	switch {
+	case g.paused:
+		return pollInterval
+	case g.speed > 0:
+		return g.moveInterval()
+	}
+
+	return 0
 }
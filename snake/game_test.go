@@ -1,12 +1,27 @@
 package snake
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/imega/snake-game/state"
+	"github.com/nsf/termbox-go"
 )
 
+func newDoubleGame(t *testing.T) *Game {
+	g, err := NewGame(newDoubleParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
 func TestDefaultGameScore(t *testing.T) {
-	g := NewGame()
+	g := newDoubleGame(t)
 
 	if g.score != 0 {
 		t.Fatalf("Initial Game Score expected to be 0 but it was %d", g.score)
@@ -15,16 +30,17 @@ func TestDefaultGameScore(t *testing.T) {
 
 func TestGameMoveInterval(t *testing.T) {
 	e := time.Duration(85) * time.Millisecond
-	g := NewGame()
+	g := newDoubleGame(t)
+	g.SetSpeed(100)
 	g.score = 150
 
-	if d := g.moveInterval(100); d != e {
+	if d := g.moveInterval(); d != e {
 		t.Fatalf("Expected move interval to be %d but got %d", e, d)
 	}
 }
 
 func TestAddPoints(t *testing.T) {
-	g := NewGame()
+	g := newDoubleGame(t)
 	s := g.score
 	g.addPoints(10)
 
@@ -34,7 +50,7 @@ func TestAddPoints(t *testing.T) {
 }
 
 func TestRetryGoBackToGameInitialState(t *testing.T) {
-	g := NewGame()
+	g := newDoubleGame(t)
 	initScore := g.score
 	initSnake := g.arena.snake
 
@@ -59,3 +75,248 @@ func TestRetryGoBackToGameInitialState(t *testing.T) {
 		t.Fatal("Expected Snake direction to have been reset")
 	}
 }
+
+func TestSteerSecondSnakeWithSecondKeys(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.steer(KeyboardEvent{EventType: MOVE, Key: termbox.KeyArrowUp, Second: true})
+	g.steer(KeyboardEvent{EventType: MOVE, Key: termbox.KeyArrowDown})
+
+	g.tick()
+
+	if g.arena.rival.direction != UP || g.arena.snake.direction != DOWN {
+		t.Fatal("Expected second player keys to steer the second snake and first player keys the first one")
+	}
+}
+
+func TestSteerSecondSnakeAgainstAI(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+	p.Versus = true
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.steer(KeyboardEvent{EventType: MOVE, Key: termbox.KeyArrowUp, Rival: true})
+	g.steer(KeyboardEvent{EventType: MOVE, Key: termbox.KeyArrowDown, Second: true})
+
+	g.tick()
+
+	if g.arena.rival.direction != UP || g.arena.snake.direction != DOWN {
+		t.Fatal("Expected the AI to steer the second snake and second player keys the first one")
+	}
+}
+
+func TestPausedGameDoesNotTick(t *testing.T) {
+	g := newDoubleGame(t)
+	h := g.arena.snake.head()
+
+	g.togglePause()
+
+	if g.tick() || g.arena.snake.head() != h {
+		t.Fatal("Expected paused game not to move")
+	}
+
+	g.togglePause()
+
+	if !g.tick() || g.arena.snake.head() == h {
+		t.Fatal("Expected resumed game to move")
+	}
+}
+
+func TestSingleStepWhilePaused(t *testing.T) {
+	g := newDoubleGame(t)
+
+	g.requestStep()
+
+	if !g.paused {
+		t.Fatal("Expected step key to pause a running game")
+	}
+
+	h := g.arena.snake.head()
+	g.requestStep()
+
+	if !g.tick() || g.arena.snake.head() == h {
+		t.Fatal("Expected requested step to move the snake")
+	}
+
+	h = g.arena.snake.head()
+
+	if g.tick() || g.arena.snake.head() != h {
+		t.Fatal("Expected game to move exactly one tick")
+	}
+}
+
+func TestLoadWaitsForTheGameLoop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newDoubleParameters()
+	p.Snapshot = filepath.Join(dir, "snapshot.json")
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.request(KeyboardEvent{EventType: SAVE})
+	g.serve()
+
+	g.tick()
+	a := g.arena
+
+	g.request(KeyboardEvent{EventType: LOAD})
+
+	if g.arena != a || g.ticks != 1 {
+		t.Fatal("Expected the load key not to touch the game before the loop serves it")
+	}
+
+	g.serve()
+
+	if g.arena == a || g.ticks != 0 || !g.paused {
+		t.Fatalf("Expected the loop to load the saved game paused but got tick %d", g.ticks)
+	}
+}
+
+func TestFinishedGameIsNotSaved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newDoubleParameters()
+	p.Snapshot = filepath.Join(dir, "snapshot.json")
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for !g.isOver {
+		g.tick()
+	}
+
+	g.request(KeyboardEvent{EventType: SAVE})
+	g.serve()
+
+	if _, err := os.Stat(p.Snapshot); !os.IsNotExist(err) {
+		t.Fatal("Expected a finished game not to be saved")
+	}
+
+	if g.notice == "" {
+		t.Fatal("Expected a notice that the finished game was not saved")
+	}
+}
+
+func TestKeysDoNotRaceTheGameLoop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := newDoubleParameters()
+	p.Human = true
+	p.Silent = true
+	p.Snapshot = filepath.Join(dir, "snapshot.json")
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.handle(KeyboardEvent{EventType: SAVE})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			g.handle(KeyboardEvent{EventType: MOVE, Key: termbox.KeyArrowUp})
+			g.handle(KeyboardEvent{EventType: PAUSE})
+			g.handle(KeyboardEvent{EventType: STEP})
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		g.request(KeyboardEvent{EventType: RETRY})
+		g.request(KeyboardEvent{EventType: LOAD})
+
+		g.mu.Lock()
+		_, _, err := g.turn(p)
+		g.mu.Unlock()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	<-done
+}
+
+func TestDrivenGameWaitsWhilePaused(t *testing.T) {
+	p := newDoubleParameters()
+	p.Silent = true
+
+	g, err := NewGame(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.handle(KeyboardEvent{EventType: PAUSE})
+
+	done := make(chan error)
+	go func() {
+		done <- g.show(p, state.Stat{})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected a paused game to wait before the next tick")
+	case <-time.After(3 * pollInterval):
+	}
+
+	g.handle(KeyboardEvent{EventType: STEP})
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the step key to let the driven game tick once")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused || g.step {
+		t.Fatal("Expected the game to stay paused after a single step")
+	}
+}
+
+func TestChangeSpeed(t *testing.T) {
+	if s := changeSpeed(100, speedUpCh); s != 90 {
+		t.Fatalf("Expected speed up to shorten the tick to 90 but got %d", s)
+	}
+
+	if s := changeSpeed(100, speedDownCh); s != 110 {
+		t.Fatalf("Expected speed down to lengthen the tick to 110 but got %d", s)
+	}
+
+	if s := changeSpeed(5, speedUpCh); s != 0 {
+		t.Fatalf("Expected speed not to go below 0 but got %d", s)
+	}
+}
@@ -10,11 +10,23 @@ const (
 	RETRY
 	END
 	SPEED
+	PAUSE
+	STEP
+	STEPBACK
+	JUMP
+	DIGIT
+	SAVE
+	LOAD
 )
 
+// KeyboardEvent is a key press, Rival marks moves meant for the second snake
+// and Second marks moves made with the keys of the second player
 type KeyboardEvent struct {
 	EventType keyboardEventType
 	Key       termbox.Key
+	Ch        rune
+	Rival     bool
+	Second    bool
 }
 
 func keyToDirection(k termbox.Key) direction {
@@ -32,27 +44,19 @@ func keyToDirection(k termbox.Key) direction {
 	}
 }
 
-func listenToKeyboard(evChan chan KeyboardEvent) {
+func listenToKeyboard(evChan chan KeyboardEvent, km *Keymap) {
 	termbox.SetInputMode(termbox.InputEsc)
 
 	for {
 		switch ev := termbox.PollEvent(); ev.Type {
 		case termbox.EventKey:
-			switch ev.Key {
-			case termbox.KeyArrowLeft:
-				evChan <- KeyboardEvent{EventType: MOVE, Key: ev.Key}
-			case termbox.KeyArrowDown:
-				evChan <- KeyboardEvent{EventType: MOVE, Key: ev.Key}
-			case termbox.KeyArrowRight:
-				evChan <- KeyboardEvent{EventType: MOVE, Key: ev.Key}
-			case termbox.KeyArrowUp:
-				evChan <- KeyboardEvent{EventType: MOVE, Key: ev.Key}
-			case termbox.KeyEsc:
-				evChan <- KeyboardEvent{EventType: END, Key: ev.Key}
-			default:
-				if ev.Ch == 'r' {
-					evChan <- KeyboardEvent{EventType: RETRY, Key: ev.Key}
-				}
+			if e, ok := km.event(ev.Key, ev.Ch); ok {
+				evChan <- e
+				continue
+			}
+
+			if ev.Ch >= '0' && ev.Ch <= '9' {
+				evChan <- KeyboardEvent{EventType: DIGIT, Ch: ev.Ch}
 			}
 		case termbox.EventError:
 			panic(ev.Err)
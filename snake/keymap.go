@@ -0,0 +1,336 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Bindable actions, the p2 ones steer the second snake
+const (
+	actionUp        = "up"
+	actionDown      = "down"
+	actionLeft      = "left"
+	actionRight     = "right"
+	actionP2Up      = "p2-up"
+	actionP2Down    = "p2-down"
+	actionP2Left    = "p2-left"
+	actionP2Right   = "p2-right"
+	actionSpeedUp   = "speed-up"
+	actionSpeedDown = "speed-down"
+	actionPause     = "pause"
+	actionStep      = "step"
+	actionStepBack  = "step-back"
+	actionJump      = "jump"
+	actionRetry     = "retry"
+	actionSave      = "save"
+	actionLoad      = "load"
+	actionQuit      = "quit"
+)
+
+// Runes carried by SPEED events
+const (
+	speedUpCh   = '+'
+	speedDownCh = '-'
+)
+
+// actionEvents is the event sent for each bindable action
+var actionEvents = map[string]KeyboardEvent{
+	actionUp:        {EventType: MOVE, Key: termbox.KeyArrowUp},
+	actionDown:      {EventType: MOVE, Key: termbox.KeyArrowDown},
+	actionLeft:      {EventType: MOVE, Key: termbox.KeyArrowLeft},
+	actionRight:     {EventType: MOVE, Key: termbox.KeyArrowRight},
+	actionP2Up:      {EventType: MOVE, Key: termbox.KeyArrowUp, Second: true},
+	actionP2Down:    {EventType: MOVE, Key: termbox.KeyArrowDown, Second: true},
+	actionP2Left:    {EventType: MOVE, Key: termbox.KeyArrowLeft, Second: true},
+	actionP2Right:   {EventType: MOVE, Key: termbox.KeyArrowRight, Second: true},
+	actionSpeedUp:   {EventType: SPEED, Ch: speedUpCh},
+	actionSpeedDown: {EventType: SPEED, Ch: speedDownCh},
+	actionPause:     {EventType: PAUSE},
+	actionStep:      {EventType: STEP},
+	actionStepBack:  {EventType: STEPBACK},
+	actionJump:      {EventType: JUMP},
+	actionRetry:     {EventType: RETRY},
+	actionSave:      {EventType: SAVE},
+	actionLoad:      {EventType: LOAD},
+	actionQuit:      {EventType: END},
+}
+
+var keyNames = map[string]termbox.Key{
+	"up":        termbox.KeyArrowUp,
+	"down":      termbox.KeyArrowDown,
+	"left":      termbox.KeyArrowLeft,
+	"right":     termbox.KeyArrowRight,
+	"esc":       termbox.KeyEsc,
+	"space":     termbox.KeySpace,
+	"enter":     termbox.KeyEnter,
+	"tab":       termbox.KeyTab,
+	"backspace": termbox.KeyBackspace2,
+	"f1":        termbox.KeyF1,
+	"f2":        termbox.KeyF2,
+	"f3":        termbox.KeyF3,
+	"f4":        termbox.KeyF4,
+	"f5":        termbox.KeyF5,
+	"f6":        termbox.KeyF6,
+	"f7":        termbox.KeyF7,
+	"f8":        termbox.KeyF8,
+	"f9":        termbox.KeyF9,
+	"f10":       termbox.KeyF10,
+	"f11":       termbox.KeyF11,
+	"f12":       termbox.KeyF12,
+}
+
+var keymapPresets = map[string]map[string][]string{
+	"arrows": {
+		actionUp:        {"Up"},
+		actionDown:      {"Down"},
+		actionLeft:      {"Left"},
+		actionRight:     {"Right"},
+		actionP2Up:      {"w", "W"},
+		actionP2Down:    {"s", "S"},
+		actionP2Left:    {"a", "A"},
+		actionP2Right:   {"d", "D"},
+		actionSpeedUp:   {"+"},
+		actionSpeedDown: {"-"},
+		actionPause:     {"p"},
+		actionStep:      {"n"},
+		actionStepBack:  {"b"},
+		actionJump:      {"g"},
+		actionRetry:     {"r"},
+		actionSave:      {"F5"},
+		actionLoad:      {"F9"},
+		actionQuit:      {"Esc"},
+	},
+	"wasd": {
+		actionUp:        {"w", "W"},
+		actionDown:      {"s", "S"},
+		actionLeft:      {"a", "A"},
+		actionRight:     {"d", "D"},
+		actionP2Up:      {"Up"},
+		actionP2Down:    {"Down"},
+		actionP2Left:    {"Left"},
+		actionP2Right:   {"Right"},
+		actionSpeedUp:   {"+"},
+		actionSpeedDown: {"-"},
+		actionPause:     {"p"},
+		actionStep:      {"n"},
+		actionStepBack:  {"b"},
+		actionJump:      {"g"},
+		actionRetry:     {"r"},
+		actionSave:      {"F5"},
+		actionLoad:      {"F9"},
+		actionQuit:      {"Esc"},
+	},
+	"vim": {
+		actionUp:        {"k"},
+		actionDown:      {"j"},
+		actionLeft:      {"h"},
+		actionRight:     {"l"},
+		actionP2Up:      {"Up"},
+		actionP2Down:    {"Down"},
+		actionP2Left:    {"Left"},
+		actionP2Right:   {"Right"},
+		actionSpeedUp:   {"+"},
+		actionSpeedDown: {"-"},
+		actionPause:     {"p"},
+		actionStep:      {"n"},
+		actionStepBack:  {"b"},
+		actionJump:      {"g"},
+		actionRetry:     {"r"},
+		actionSave:      {"F5"},
+		actionLoad:      {"F9"},
+		actionQuit:      {"Esc", "q"},
+	},
+}
+
+// defaultKeymap is the preset used when no key bindings are given
+const defaultKeymap = "arrows"
+
+type keyBinding struct {
+	key termbox.Key
+	ch  rune
+}
+
+// Keymap binds keys to game actions.
+//
+// A key-binding file is a JSON object naming a preset to start from and
+// the actions to rebind, every listed action loses the keys of the preset.
+// Keys are single characters or one of Up, Down, Left, Right, Esc,
+// Space, Enter, Tab, Backspace and F1 to F12.
+//
+//	{
+//	  "preset": "vim",
+//	  "bindings": {
+//	    "pause": ["Space"],
+//	    "retry": ["r", "Enter"]
+//	  }
+//	}
+type Keymap struct {
+	bindings map[keyBinding]string
+	// hints is the first key of each action as shown on screen
+	hints map[string]string
+}
+
+type keymapFile struct {
+	Preset   string              `json:"preset"`
+	Bindings map[string][]string `json:"bindings"`
+}
+
+// LoadKeymap loads a key-binding preset by its name or a key-binding
+// file by its path, the arrows preset is used when the name is empty
+func LoadKeymap(name string) (*Keymap, error) {
+	if name == "" {
+		name = defaultKeymap
+	}
+
+	if _, ok := keymapPresets[name]; ok {
+		return newKeymap(name, nil)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key bindings, %s", err)
+	}
+	defer f.Close()
+
+	km, err := ParseKeymap(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key bindings %s, %s", name, err)
+	}
+
+	return km, nil
+}
+
+// ParseKeymap reads and validates a key-binding file
+func ParseKeymap(r io.Reader) (*Keymap, error) {
+	var kf keymapFile
+	if err := json.NewDecoder(r).Decode(&kf); err != nil {
+		return nil, err
+	}
+
+	if kf.Preset == "" {
+		kf.Preset = defaultKeymap
+	}
+
+	return newKeymap(kf.Preset, kf.Bindings)
+}
+
+func newKeymap(preset string, overrides map[string][]string) (*Keymap, error) {
+	base, ok := keymapPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q", preset)
+	}
+
+	actions := make(map[string][]string, len(base))
+	for a, keys := range base {
+		actions[a] = keys
+	}
+
+	for a, keys := range overrides {
+		if _, ok := actionEvents[a]; !ok {
+			return nil, fmt.Errorf("unknown action %q", a)
+		}
+
+		actions[a] = keys
+	}
+
+	names := make([]string, 0, len(actions))
+	for a := range actions {
+		names = append(names, a)
+	}
+
+	sort.Strings(names)
+
+	km := &Keymap{bindings: make(map[keyBinding]string), hints: make(map[string]string)}
+
+	for _, a := range names {
+		for i, k := range actions[a] {
+			b, err := parseKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key for %s, %s", a, err)
+			}
+
+			if i == 0 {
+				km.hints[a] = keyHint(k)
+			}
+
+			if other, ok := km.bindings[b]; ok {
+				return nil, fmt.Errorf("key %q is bound to both %s and %s", k, other, a)
+			}
+
+			km.bindings[b] = a
+		}
+	}
+
+	return km, nil
+}
+
+func parseKey(s string) (keyBinding, error) {
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		if r == ' ' {
+			return keyBinding{key: termbox.KeySpace}, nil
+		}
+
+		return keyBinding{ch: r}, nil
+	}
+
+	if k, ok := keyNames[strings.ToLower(s)]; ok {
+		return keyBinding{key: k}, nil
+	}
+
+	return keyBinding{}, fmt.Errorf("unknown key %q", s)
+}
+
+// keyHint is the name of the key as shown on screen, upper case for the
+// named keys
+func keyHint(s string) string {
+	if s == " " {
+		return "SPACE"
+	}
+
+	if utf8.RuneCountInString(s) == 1 {
+		return s
+	}
+
+	return strings.ToUpper(s)
+}
+
+// hint returns the first key bound to the action as shown on screen, it
+// is empty when no key is bound
+func (km *Keymap) hint(action string) string {
+	return km.hints[action]
+}
+
+// event returns the event bound to the key press, if any
+func (km *Keymap) event(key termbox.Key, ch rune) (KeyboardEvent, bool) {
+	b := keyBinding{ch: ch}
+	if ch == 0 {
+		b = keyBinding{key: key}
+	}
+
+	a, ok := km.bindings[b]
+	if !ok {
+		return KeyboardEvent{}, false
+	}
+
+	return actionEvents[a], true
+}
+
+// KeymapPresets returns the names of the built-in key-binding presets
+func KeymapPresets() []string {
+	names := make([]string, 0, len(keymapPresets))
+	for n := range keymapPresets {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,123 @@
+package snake
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestDefaultKeymap(t *testing.T) {
+	km, err := LoadKeymap("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, ok := km.event(termbox.KeyArrowUp, 0); !ok || e.EventType != MOVE || e.Key != termbox.KeyArrowUp || e.Second {
+		t.Fatalf("Expected arrow up to move the first snake up but got %+v", e)
+	}
+
+	if e, ok := km.event(0, 'W'); !ok || e.Key != termbox.KeyArrowUp || !e.Second {
+		t.Fatalf("Expected W to move the second snake up but got %+v", e)
+	}
+
+	if e, ok := km.event(termbox.KeyEsc, 0); !ok || e.EventType != END {
+		t.Fatalf("Expected Esc to quit but got %+v", e)
+	}
+
+	if e, ok := km.event(0, '+'); !ok || e.EventType != SPEED || e.Ch != speedUpCh {
+		t.Fatalf("Expected + to speed up but got %+v", e)
+	}
+
+	if _, ok := km.event(0, 'x'); ok {
+		t.Fatal("Expected x not to be bound")
+	}
+}
+
+func TestVimKeymap(t *testing.T) {
+	km, err := LoadKeymap("vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[rune]termbox.Key{
+		'h': termbox.KeyArrowLeft,
+		'j': termbox.KeyArrowDown,
+		'k': termbox.KeyArrowUp,
+		'l': termbox.KeyArrowRight,
+	}
+
+	for ch, want := range cases {
+		if e, ok := km.event(0, ch); !ok || e.Key != want || e.Second {
+			t.Fatalf("Expected %q to move the first snake but got %+v", ch, e)
+		}
+	}
+}
+
+func TestParseKeymapOverridesPreset(t *testing.T) {
+	km, err := ParseKeymap(strings.NewReader(`{
+		"preset": "wasd",
+		"bindings": {"pause": ["Space"], "retry": ["r", "Enter"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, ok := km.event(termbox.KeySpace, 0); !ok || e.EventType != PAUSE {
+		t.Fatalf("Expected Space to pause but got %+v", e)
+	}
+
+	if _, ok := km.event(0, 'p'); ok {
+		t.Fatal("Expected p to be unbound once pause is rebound")
+	}
+
+	if e, ok := km.event(termbox.KeyEnter, 0); !ok || e.EventType != RETRY {
+		t.Fatalf("Expected Enter to retry but got %+v", e)
+	}
+
+	if e, ok := km.event(0, 'd'); !ok || e.Key != termbox.KeyArrowRight || e.Second {
+		t.Fatalf("Expected d to move the first snake right but got %+v", e)
+	}
+}
+
+func TestParseKeymapRejectsInvalidBindings(t *testing.T) {
+	cases := map[string]string{
+		"not json":       `preset: vim`,
+		"unknown preset": `{"preset": "emacs"}`,
+		"unknown action": `{"bindings": {"fly": ["j"]}}`,
+		"unknown key":    `{"bindings": {"pause": ["F13"]}}`,
+		"duplicate key":  `{"bindings": {"pause": ["r"]}}`,
+	}
+
+	for name, in := range cases {
+		if _, err := ParseKeymap(strings.NewReader(in)); err == nil {
+			t.Fatalf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestPauseHintFollowsTheBindings(t *testing.T) {
+	cases := map[string]struct {
+		in    string
+		pause string
+		quit  string
+	}{
+		"preset":  {`{}`, "p resume, n step", "ESC"},
+		"rebound": {`{"bindings": {"pause": ["Space"], "step": [], "quit": ["q", "Esc"]}}`, "SPACE resume", "q"},
+	}
+
+	for name, c := range cases {
+		km, err := ParseKeymap(strings.NewReader(c.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if h := pauseHint(km); h != c.pause {
+			t.Fatalf("%s: expected pause hint %q but got %q", name, c.pause, h)
+		}
+
+		if h := km.hint(actionQuit); h != c.quit {
+			t.Fatalf("%s: expected quit key %q but got %q", name, c.quit, h)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package snake
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/imega/snake-game/state"
+)
+
+const (
+	levelWall  = '#'
+	levelEmpty = '.'
+)
+
+// Level describes an arena loaded from a level file.
+//
+// A level file starts with a header of "key: value" lines, where lines
+// starting with "#" are comments, followed by a blank line and an ASCII
+// map where "#" is a wall and "." or a space is an empty cell. The top
+// line of the map is the top row of the arena. The map may be omitted
+// when the header sets both width and height.
+//
+//	name: box
+//	start: 2,1
+//	length: 4
+//	direction: right
+//	speed: 100
+//	food: 10
+//	foods: 1
+//	kinds: fruit, cake
+//	lifetime: 150
+//	wrap: no
+//
+//	##########
+//	#........#
+//	##########
+type Level struct {
+	Name       string
+	Width      int
+	Height     int
+	StartX     int
+	StartY     int
+	Length     int
+	Direction  string
+	Wrap       bool
+	Speed      int
+	FoodPoints int
+	FoodCount  int
+	FoodKinds  []string
+	Lifetime   int
+	Obstacles  []state.Coord
+}
+
+// LoadLevel loads a built-in level by its name or a level file by its path
+func LoadLevel(name string) (*Level, error) {
+	if l, ok := builtinLevels[name]; ok {
+		return ParseLevel(strings.NewReader(l))
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open level, %s", err)
+	}
+	defer f.Close()
+
+	l, err := ParseLevel(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse level %s, %s", name, err)
+	}
+
+	return l, nil
+}
+
+// ParseLevel reads and validates a level file
+func ParseLevel(r io.Reader) (*Level, error) {
+	l := &Level{
+		StartX:     1,
+		StartY:     1,
+		Length:     4,
+		Direction:  "right",
+		FoodPoints: 10,
+		FoodCount:  1,
+	}
+
+	var (
+		rows    []string
+		inMap   bool
+		lineNum int
+		sc      = bufio.NewScanner(r)
+	)
+
+	for sc.Scan() {
+		lineNum++
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		switch {
+		case inMap:
+			rows = append(rows, line)
+		case strings.TrimSpace(line) == "":
+			inMap = true
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			if err := l.parseHeader(line); err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNum, err)
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read level, %s", err)
+	}
+
+	for len(rows) > 0 && rows[len(rows)-1] == "" {
+		rows = rows[:len(rows)-1]
+	}
+
+	if err := l.parseMap(rows); err != nil {
+		return nil, err
+	}
+
+	if err := validateParameters(l.parameters(state.Parameters{})); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Level) parseHeader(line string) error {
+	kv := strings.SplitN(line, ":", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("expected \"key: value\" but got %q", line)
+	}
+
+	var (
+		key   = strings.ToLower(strings.TrimSpace(kv[0]))
+		value = strings.TrimSpace(kv[1])
+		err   error
+	)
+
+	switch key {
+	case "name":
+		l.Name = value
+	case "width":
+		l.Width, err = parsePositive(value)
+	case "height":
+		l.Height, err = parsePositive(value)
+	case "start":
+		l.StartX, l.StartY, err = parseCell(value)
+	case "length":
+		l.Length, err = parsePositive(value)
+	case "direction":
+		_, err = parseDirection(value)
+		l.Direction = value
+	case "speed":
+		l.Speed, err = parsePositive(value)
+	case "food":
+		l.FoodPoints, err = parsePositive(value)
+	case "foods":
+		l.FoodCount, err = parsePositive(value)
+	case "kinds":
+		l.FoodKinds = splitList(value)
+		_, err = lookupFoodKinds(l.FoodKinds)
+	case "lifetime":
+		l.Lifetime, err = parsePositive(value)
+	case "wrap":
+		l.Wrap, err = parseYesNo(value)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s, %s", key, err)
+	}
+
+	return nil
+}
+
+func (l *Level) parseMap(rows []string) error {
+	if len(rows) == 0 {
+		if l.Width == 0 || l.Height == 0 {
+			return fmt.Errorf("level without a map needs width and height")
+		}
+
+		return nil
+	}
+
+	width := len([]rune(rows[0]))
+
+	if l.Width != 0 && l.Width != width {
+		return fmt.Errorf("map is %d cells wide but width is %d", width, l.Width)
+	}
+
+	if l.Height != 0 && l.Height != len(rows) {
+		return fmt.Errorf("map is %d rows high but height is %d", len(rows), l.Height)
+	}
+
+	l.Width, l.Height = width, len(rows)
+
+	for i, row := range rows {
+		cells := []rune(row)
+		if len(cells) != width {
+			return fmt.Errorf("map row %d has %d cells, expected %d", i+1, len(cells), width)
+		}
+
+		for j, c := range cells {
+			switch c {
+			case levelWall:
+				l.Obstacles = append(l.Obstacles, state.Coord{X: j, Y: l.Height - 1 - i})
+			case levelEmpty, ' ':
+			default:
+				return fmt.Errorf("unexpected %q at map row %d column %d", c, i+1, j+1)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Apply copies the level into the game parameters
+func (l *Level) Apply(p *state.Parameters) {
+	*p = l.parameters(*p)
+}
+
+func (l *Level) parameters(p state.Parameters) state.Parameters {
+	p.Width = l.Width
+	p.Height = l.Height
+	p.StartX = l.StartX
+	p.StartY = l.StartY
+	p.SnakeLength = l.Length
+	p.StartDirection = l.Direction
+	p.Wrap = l.Wrap
+	p.FoodPoints = l.FoodPoints
+	p.FoodCount = l.FoodCount
+	p.FoodKinds = l.FoodKinds
+	p.FoodLifetime = l.Lifetime
+	p.Obstacles = l.Obstacles
+
+	if l.Speed > 0 {
+		p.Speed = l.Speed
+	}
+
+	return p
+}
+
+func parsePositive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+
+	if n < 1 {
+		return 0, fmt.Errorf("%d is not positive", n)
+	}
+
+	return n, nil
+}
+
+func parseCell(s string) (int, int, error) {
+	xy := strings.Split(s, ",")
+	if len(xy) != 2 {
+		return 0, 0, fmt.Errorf("expected x,y but got %q", s)
+	}
+
+	x, err := strconv.Atoi(strings.TrimSpace(xy[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a column", xy[0])
+	}
+
+	y, err := strconv.Atoi(strings.TrimSpace(xy[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a row", xy[1])
+	}
+
+	return x, y, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			items = append(items, v)
+		}
+	}
+
+	return items
+}
+
+func parseYesNo(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "true", "on":
+		return true, nil
+	case "no", "false", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected yes or no but got %q", s)
+	}
+}
+
+// Levels returns the names of the built-in levels
+func Levels() []string {
+	names := make([]string, 0, len(builtinLevels))
+	for n := range builtinLevels {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,115 @@
+package snake
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func TestParseLevel(t *testing.T) {
+	l, err := ParseLevel(strings.NewReader(`# comment
+name: tiny
+start: 1,1
+length: 2
+direction: right
+speed: 80
+food: 20
+kinds: fruit, taco
+lifetime: 50
+wrap: yes
+
+######
+#....#
+#....#
+######
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Name != "tiny" || l.Width != 6 || l.Height != 4 || l.Speed != 80 || l.FoodPoints != 20 || !l.Wrap {
+		t.Fatalf("Unexpected level %+v", l)
+	}
+
+	if l.Lifetime != 50 {
+		t.Fatalf("Expected lifetime 50 but got %d", l.Lifetime)
+	}
+
+	if len(l.FoodKinds) != 2 || l.FoodKinds[1] != "taco" {
+		t.Fatalf("Expected fruit and taco but got %v", l.FoodKinds)
+	}
+
+	if len(l.Obstacles) != 16 {
+		t.Fatalf("Expected 16 walls but got %d", len(l.Obstacles))
+	}
+
+	if o := l.Obstacles[0]; o.X != 0 || o.Y != 3 {
+		t.Fatalf("Expected top row walls to be at y=3 but got %v", o)
+	}
+}
+
+func TestParseLevelWithoutMap(t *testing.T) {
+	l, err := ParseLevel(strings.NewReader("width: 30\nheight: 10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Width != 30 || l.Height != 10 || len(l.Obstacles) != 0 {
+		t.Fatalf("Unexpected level %+v", l)
+	}
+}
+
+func TestParseLevelKeepsRowsOfSpaces(t *testing.T) {
+	l, err := ParseLevel(strings.NewReader("start: 1,0\nlength: 2\n\n#####\n#...#\n     \n     \n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Width != 5 || l.Height != 4 || len(l.Obstacles) != 7 {
+		t.Fatalf("Expected a 5x4 arena with 7 walls but got %dx%d with %d", l.Width, l.Height, len(l.Obstacles))
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":       "colour: red\n",
+		"unknown food kind": "kinds: brick\nwidth: 10\nheight: 10\n",
+		"not a key value":   "width 10\n",
+		"bad number":        "width: ten\n",
+		"bad direction":     "direction: north\nwidth: 10\nheight: 10\n",
+		"no size":           "name: empty\n",
+		"ragged map":        "name: x\n\n........\n.....\n",
+		"unknown cell":      "name: x\n\n........\n...x....\n........\n",
+		"width mismatch":    "width: 4\n\n........\n........\n........\n",
+		"snake in the wall": "start: 0,2\n\n########\n........\n........\n",
+	}
+
+	for name, level := range cases {
+		if _, err := ParseLevel(strings.NewReader(level)); err == nil {
+			t.Fatalf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestBuiltinLevelsAreValid(t *testing.T) {
+	for _, name := range Levels() {
+		l, err := LoadLevel(name)
+		if err != nil {
+			t.Fatalf("Level %s: %s", name, err)
+		}
+
+		p := state.Parameters{}
+		l.Apply(&p)
+
+		if _, err := NewEngine(p); err != nil {
+			t.Fatalf("Level %s: %s", name, err)
+		}
+	}
+}
+
+func TestLoadLevelMissingFile(t *testing.T) {
+	if _, err := LoadLevel("no-such-level.txt"); err == nil {
+		t.Fatal("Expected missing level file to be rejected")
+	}
+}
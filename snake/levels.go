@@ -0,0 +1,79 @@
+package snake
+
+// builtinLevels are shipped with the binary and can be loaded by name
+var builtinLevels = map[string]string{
+	"classic": `# Open 50x20 arena
+name: classic
+width: 50
+height: 20
+`,
+	"donut": `# Open arena where the edges wrap around
+name: donut
+width: 40
+height: 16
+wrap: yes
+`,
+	"box": `# Arena fenced in by walls
+name: box
+start: 2,2
+
+########################################
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+#......................................#
+########################################
+`,
+	"cross": `# Open arena with a cross in the middle
+name: cross
+
+........................................
+........................................
+........................................
+....................#...................
+....................#...................
+....................#...................
+....................#...................
+....................#...................
+........########################........
+....................#...................
+....................#...................
+....................#...................
+....................#...................
+....................#...................
+........................................
+........................................
+........................................
+`,
+	"rooms": `# Four rooms joined by narrow doors
+name: rooms
+start: 2,2
+
+########################################
+#...................#..................#
+#...................#..................#
+#......................................#
+#......................................#
+#...................#..................#
+#...................#..................#
+#...................#..................#
+#########..##################..#########
+#...................#..................#
+#...................#..................#
+#......................................#
+#......................................#
+#...................#..................#
+#...................#..................#
+########################################
+`,
+}
@@ -0,0 +1,262 @@
+package snake
+
+import (
+	"fmt"
+
+	"github.com/imega/snake-game/state"
+)
+
+// Game modes, each ends the game its own way
+const (
+	// ModeEndless goes on until the snake dies
+	ModeEndless = "endless"
+	// ModeTimeAttack ends when the time limit is up
+	ModeTimeAttack = "time-attack"
+	// ModeSurvival speeds the game up every survivalTicks until the snake dies
+	ModeSurvival = "survival"
+	// ModeTarget ends when the target score is reached
+	ModeTarget = "target"
+)
+
+var modes = []string{ModeEndless, ModeTimeAttack, ModeSurvival, ModeTarget}
+
+const (
+	// defaultTimeLimit is the time attack limit in seconds when none is given
+	defaultTimeLimit = 60
+	// defaultTarget is the score to reach when none is given
+	defaultTarget = 200
+	// survivalTicks is how many ticks survival mode waits between speed ups
+	survivalTicks = 50
+)
+
+// Modes returns the names of the game modes
+func Modes() []string {
+	return append([]string(nil), modes...)
+}
+
+// gameMode returns the mode of the parameters, endless when none is given
+func gameMode(p state.Parameters) string {
+	if p.Mode == "" {
+		return ModeEndless
+	}
+
+	return p.Mode
+}
+
+func validateMode(p state.Parameters) error {
+	found := false
+	for _, m := range modes {
+		found = found || m == gameMode(p)
+	}
+
+	switch {
+	case !found:
+		return fmt.Errorf("unknown mode %q", p.Mode)
+	case p.TimeLimit < 0:
+		return fmt.Errorf("invalid time limit %d", p.TimeLimit)
+	case p.Target < 0:
+		return fmt.Errorf("invalid target score %d", p.Target)
+	}
+
+	return nil
+}
+
+// timeLimit is the time attack limit in milliseconds of game time
+func (e *Engine) timeLimit() int {
+	if e.params.TimeLimit > 0 {
+		return e.params.TimeLimit * 1000
+	}
+
+	return defaultTimeLimit * 1000
+}
+
+func (e *Engine) target() int {
+	if e.params.Target > 0 {
+		return e.params.Target
+	}
+
+	return defaultTarget
+}
+
+// interval is how many milliseconds a tick lasts at the speed of the game
+func (e *Engine) interval() int {
+	return e.speed - e.score/10 - e.pace - e.powerPace()
+}
+
+// SetSpeed changes how many milliseconds a tick lasts from the next tick
+// on, the change is recorded so the game time of a replay stays the same
+func (e *Engine) SetSpeed(ms int) {
+	if ms < 0 {
+		ms = 0
+	}
+
+	if ms == e.speed {
+		return
+	}
+
+	e.speed = ms
+	e.speeds = append(e.speeds, SpeedChange{Tick: e.ticks, Speed: ms})
+}
+
+// clock adds the tick to the game time, a tick counts at least a
+// millisecond so the time runs out even at full speed
+func (e *Engine) clock() {
+	ms := e.interval()
+	if ms < 1 {
+		ms = 1
+	}
+
+	e.elapsed += ms
+}
+
+// timeLeft is how many milliseconds of game time are left in time attack
+func (e *Engine) timeLeft() int {
+	if gameMode(e.params) != ModeTimeAttack || e.elapsed >= e.timeLimit() {
+		return 0
+	}
+
+	return e.timeLimit() - e.elapsed
+}
+
+// applyMode speeds survival up, ends time attack when the time is up and
+// target when a snake reaches the target score, the better score wins a
+// duel in both
+func (e *Engine) applyMode() {
+	switch gameMode(e.params) {
+	case ModeSurvival:
+		if e.ticks%survivalTicks == 0 {
+			e.pace += paceStep
+		}
+	case ModeTimeAttack:
+		if e.elapsed < e.timeLimit() {
+			return
+		}
+
+		for i := range e.arena.players() {
+			if e.deaths[i] == NoDeath {
+				e.deaths[i] = DeathTimeout
+			}
+		}
+
+		e.end()
+		e.decideByScore()
+	case ModeTarget:
+		if e.score < e.target() && e.rivalScore < e.target() {
+			return
+		}
+
+		e.reached = true
+		e.end()
+		e.decideByScore()
+	}
+}
+
+func (e *Engine) decideByScore() {
+	if e.arena.rival == nil {
+		return
+	}
+
+	switch {
+	case e.score > e.rivalScore:
+		e.winner = 1
+	case e.rivalScore > e.score:
+		e.winner = 2
+	default:
+		e.winner = 0
+	}
+}
+
+// result is what the finished game counts for in its mode, the fewest
+// ticks to the target or the most points otherwise, a target game that
+// did not reach the target does not count
+func (e *Engine) result() (int, bool) {
+	if gameMode(e.params) != ModeTarget {
+		return e.score, true
+	}
+
+	return e.ticks, e.reached
+}
+
+// betterResult tells whether a beats b in the mode
+func betterResult(mode string, a, b int) bool {
+	if mode == ModeTarget {
+		return a < b
+	}
+
+	return a > b
+}
+
+// keepBest remembers the result of the finished game when it is the best
+// of its mode in this session
+func (g *Game) keepBest() {
+	r, ok := g.result()
+	if !ok {
+		return
+	}
+
+	m := gameMode(g.params)
+	if best, seen := g.best[m]; seen && !betterResult(m, r, best) {
+		return
+	}
+
+	if g.best == nil {
+		g.best = make(map[string]int)
+	}
+
+	g.best[m] = r
+}
+
+// modeStatus is the mode part of the score line
+func (g *Game) modeStatus() string {
+	m := gameMode(g.params)
+
+	var s string
+
+	switch m {
+	case ModeTimeAttack:
+		s = fmt.Sprintf("Time: %ds", (g.timeLeft()+999)/1000)
+	case ModeSurvival:
+		s = fmt.Sprintf("Speed: %d", g.ticks/survivalTicks+1)
+	case ModeTarget:
+		s = fmt.Sprintf("Target: %d", g.target())
+	}
+
+	best, ok := g.best[m]
+	if !ok {
+		return s
+	}
+
+	if s != "" {
+		s += "  "
+	}
+
+	if m == ModeTarget {
+		return s + fmt.Sprintf("Best: %d ticks", best)
+	}
+
+	return s + fmt.Sprintf("Best: %d", best)
+}
+
+// modeTitle names the mode in the title
+func modeTitle(p state.Parameters) string {
+	switch gameMode(p) {
+	case ModeTimeAttack:
+		limit := p.TimeLimit
+		if limit == 0 {
+			limit = defaultTimeLimit
+		}
+
+		return fmt.Sprintf("time attack, %ds", limit)
+	case ModeSurvival:
+		return "survival"
+	case ModeTarget:
+		target := p.Target
+		if target == 0 {
+			target = defaultTarget
+		}
+
+		return fmt.Sprintf("race to %d", target)
+	default:
+		return ""
+	}
+}
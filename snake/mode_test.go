@@ -0,0 +1,166 @@
+package snake
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func newDoubleModeEngine(t *testing.T, mode string) *Engine {
+	p := newDoubleParameters()
+	p.Mode = mode
+	p.Speed = 100
+	p.TimeLimit = 1
+	p.Target = 20
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.arena.findFood = func(*arena, coord) int {
+		return -1
+	}
+
+	return e
+}
+
+func TestTimeAttackEndsWhenTimeIsUp(t *testing.T) {
+	e := newDoubleModeEngine(t, ModeTimeAttack)
+
+	st, _, done := e.Step(NOOP)
+	if done || st.TimeLeft != 900 {
+		t.Fatalf("Expected 900 ms left after a tick but got %d", st.TimeLeft)
+	}
+
+	for i := 0; i < 20 && !done; i++ {
+		st, _, done = e.Step(NOOP)
+	}
+
+	if !done || e.ticks != 10 || st.DeathCause != "timeout" {
+		t.Fatalf("Expected time to run out on tick 10 but got %q on tick %d", st.DeathCause, e.ticks)
+	}
+}
+
+func TestTimeAttackReplayKeepsTheGameSpeed(t *testing.T) {
+	p := newDoubleParameters()
+	p.Mode = ModeTimeAttack
+	p.Speed = 100
+	p.TimeLimit = 1
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Reset(7)
+
+	for i := 0; i < 3; i++ {
+		e.Step(NOOP)
+	}
+
+	e.SetSpeed(50)
+
+	for i := 0; i < 30 && !e.isOver; i++ {
+		e.Step(NOOP)
+	}
+
+	if !e.isOver || e.ticks != 17 {
+		t.Fatalf("Expected time to run out on tick 17 at the new speed but got tick %d", e.ticks)
+	}
+
+	pb, err := NewPlayback(e.Replay(), state.Parameters{Speed: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pb.Seek(pb.replay.Ticks)
+
+	if !reflect.DeepEqual(pb.State(), e.State()) || pb.speed != 10 {
+		t.Fatalf("Expected a faster playback to end like the game in %+v but got %+v", e.State(), pb.State())
+	}
+}
+
+func TestTimeAttackDuelIsWonOnPoints(t *testing.T) {
+	p := newDoubleParameters()
+	p.TwoPlayers = true
+	p.Mode = ModeTimeAttack
+	p.Speed = 500
+	p.TimeLimit = 1
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.rivalScore = 10
+	e.Step(NOOP)
+	st, _, done := e.Step(NOOP)
+
+	if !done || st.Winner != 2 {
+		t.Fatalf("Expected the second snake to win on points but got %d", st.Winner)
+	}
+}
+
+func TestSurvivalSpeedsUp(t *testing.T) {
+	e := newDoubleModeEngine(t, ModeSurvival)
+	e.arena.snake.direction = UP
+
+	for i := 0; i < survivalTicks; i++ {
+		e.arena.snake.body = []coord{{x: 1, y: 1}}
+		e.Step(NOOP)
+	}
+
+	if e.pace != paceStep {
+		t.Fatalf("Expected survival to speed up by %d after %d ticks but got %d", paceStep, survivalTicks, e.pace)
+	}
+}
+
+func TestTargetModeEndsWhenTargetIsReached(t *testing.T) {
+	e := newDoubleModeEngine(t, ModeTarget)
+	e.arena.findFood = func(*arena, coord) int {
+		return 0
+	}
+
+	st, _, done := e.Step(NOOP)
+	if done {
+		t.Fatal("Expected game to go on below the target")
+	}
+
+	st, _, done = e.Step(NOOP)
+	if !done || !st.TargetReached || st.DeathCause != "" {
+		t.Fatalf("Expected target to be reached but got %+v", st)
+	}
+
+	if r, ok := e.result(); !ok || r != 2 {
+		t.Fatalf("Expected the result to be 2 ticks but got %d", r)
+	}
+}
+
+func TestKeepBestPerMode(t *testing.T) {
+	g := &Game{Engine: newDoubleModeEngine(t, ModeTarget)}
+
+	for _, ticks := range []int{30, 20, 25} {
+		g.ticks = ticks
+		g.reached = true
+		g.keepBest()
+	}
+
+	g.params.Mode = ModeEndless
+	g.score = 40
+	g.keepBest()
+
+	if g.best[ModeTarget] != 20 || g.best[ModeEndless] != 40 {
+		t.Fatalf("Expected separate bests of 20 ticks and 40 points but got %v", g.best)
+	}
+}
+
+func TestNewEngineRejectsUnknownMode(t *testing.T) {
+	p := newDoubleParameters()
+	p.Mode = "zen"
+
+	if _, err := NewEngine(p); err == nil {
+		t.Fatal("Expected unknown mode to be rejected")
+	}
+}
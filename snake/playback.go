@@ -0,0 +1,173 @@
+package snake
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/imega/snake-game/state"
+	"github.com/nsf/termbox-go"
+)
+
+// maxJumpDigits bounds the tick typed for a jump
+const maxJumpDigits = 9
+
+// Playback plays a recorded game back through the engine, it can pause,
+// step forward and back, jump to a tick and change the playback speed
+type Playback struct {
+	*Game
+	replay *Replay
+	// next is the index of the first turn not played yet and nextSpeed
+	// the one of the first speed change
+	next      int
+	nextSpeed int
+	// speed is the delay between the ticks of the playback, the game keeps
+	// the recorded speed
+	speed int
+	jump  string
+}
+
+// NewPlayback creates a playback of the replay, the playback speed and the
+// keys are taken from the parameters, everything else from the replay
+func NewPlayback(r *Replay, p state.Parameters) (*Playback, error) {
+	params := r.Parameters
+	params.Keys = p.Keys
+	params.Snapshot = p.Snapshot
+	params.Silent = false
+	params.Record = ""
+
+	g, err := NewGame(params)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &Playback{Game: g, replay: r, speed: p.Speed}
+	pb.Seek(0)
+
+	return pb, nil
+}
+
+// Seek plays the game again from its start up to the tick
+func (pb *Playback) Seek(tick int) {
+	if tick < 0 {
+		tick = 0
+	}
+
+	pb.Reset(pb.replay.Seed)
+	pb.next = 0
+	pb.nextSpeed = 0
+
+	for pb.ticks < tick {
+		if !pb.advance() {
+			return
+		}
+	}
+}
+
+// Pause stops the playback until it is resumed or stepped
+func (pb *Playback) Pause() {
+	pb.paused = true
+}
+
+// Tick returns the number of ticks played so far
+func (pb *Playback) Tick() int {
+	return pb.ticks
+}
+
+// advance plays the next recorded tick and tells whether there was one
+func (pb *Playback) advance() bool {
+	if pb.ticks >= pb.replay.Ticks {
+		return false
+	}
+
+	players := pb.arena.players()
+	turns := pb.replay.Turns
+
+	for ; pb.next < len(turns) && turns[pb.next].Tick == pb.ticks; pb.next++ {
+		d, _ := parseDirection(turns[pb.next].Direction)
+		players[turns[pb.next].Player-1].changeDirection(d)
+	}
+
+	speeds := pb.replay.Speeds
+
+	for ; pb.nextSpeed < len(speeds) && speeds[pb.nextSpeed].Tick == pb.ticks; pb.nextSpeed++ {
+		pb.SetSpeed(speeds[pb.nextSpeed].Speed)
+	}
+
+	pb.Step(NOOP)
+
+	return true
+}
+
+func (pb *Playback) handle(e KeyboardEvent) {
+	switch e.EventType {
+	case PAUSE:
+		pb.togglePause()
+	case STEP:
+		if !pb.paused {
+			pb.paused = true
+			return
+		}
+
+		pb.advance()
+	case STEPBACK:
+		pb.paused = true
+		pb.Seek(pb.ticks - 1)
+	case DIGIT:
+		if len(pb.jump) < maxJumpDigits {
+			pb.jump += string(e.Ch)
+		}
+	case JUMP:
+		tick, _ := strconv.Atoi(pb.jump)
+		pb.jump = ""
+		pb.Seek(tick)
+	case RETRY:
+		pb.Seek(0)
+	case SPEED:
+		pb.speed = changeSpeed(pb.speed, e.Ch)
+	case SAVE:
+		pb.saveSnapshot()
+	}
+}
+
+func (pb *Playback) status() string {
+	s := fmt.Sprintf("Replay tick %d of %d, %d ms per tick", pb.ticks, pb.replay.Ticks, pb.speed)
+	if pb.jump != "" {
+		s += fmt.Sprintf(", go to %s_", pb.jump)
+	}
+
+	return s
+}
+
+// Start shows the playback in the terminal until ESC is pressed
+func (pb *Playback) Start() error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	defer termbox.Close()
+
+	go listenToKeyboard(KeyboardEventsChan, pb.keymap)
+
+	for {
+		pb.title = pb.status()
+		if err := pb.render(pb.params, state.Stat{}); err != nil {
+			return err
+		}
+
+		var next <-chan time.Time
+		if !pb.paused && pb.ticks < pb.replay.Ticks {
+			next = time.After(time.Duration(pb.speed) * time.Millisecond)
+		}
+
+		select {
+		case e := <-KeyboardEventsChan:
+			if e.EventType == END {
+				return nil
+			}
+
+			pb.handle(e)
+		case <-next:
+			pb.advance()
+		}
+	}
+}
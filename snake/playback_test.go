@@ -0,0 +1,77 @@
+package snake
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func newDoublePlayback(t *testing.T) (*Playback, *Engine) {
+	e := newDoubleEngine(t)
+	playRecordedGame(e, []Action{MoveUp, NOOP, NOOP, MoveRight, NOOP, MoveDown})
+
+	pb, err := NewPlayback(e.Replay(), state.Parameters{Speed: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pb, e
+}
+
+func TestPlaybackReachesRecordedEnd(t *testing.T) {
+	pb, e := newDoublePlayback(t)
+
+	pb.Seek(pb.replay.Ticks)
+
+	if !reflect.DeepEqual(pb.State(), e.State()) {
+		t.Fatalf("Expected playback to end in %+v but got %+v", e.State(), pb.State())
+	}
+}
+
+func TestPlaybackStepsBackAndForth(t *testing.T) {
+	pb, _ := newDoublePlayback(t)
+
+	pb.Seek(5)
+	want := pb.State()
+
+	pb.handle(KeyboardEvent{EventType: STEP})
+	if !pb.paused || pb.Tick() != 5 {
+		t.Fatal("Expected first step to pause the playback")
+	}
+
+	pb.handle(KeyboardEvent{EventType: STEP})
+	pb.handle(KeyboardEvent{EventType: STEPBACK})
+
+	if pb.Tick() != 5 || !reflect.DeepEqual(pb.State(), want) {
+		t.Fatalf("Expected to be back at tick 5 but got tick %d", pb.Tick())
+	}
+}
+
+func TestPlaybackJumpsToTypedTick(t *testing.T) {
+	pb, _ := newDoublePlayback(t)
+
+	pb.handle(KeyboardEvent{EventType: DIGIT, Ch: '0'})
+	pb.handle(KeyboardEvent{EventType: DIGIT, Ch: '7'})
+	pb.handle(KeyboardEvent{EventType: JUMP})
+
+	if pb.Tick() != 7 || pb.jump != "" {
+		t.Fatalf("Expected to jump to tick 7 but got %d", pb.Tick())
+	}
+
+	pb.handle(KeyboardEvent{EventType: JUMP})
+
+	if pb.Tick() != 0 {
+		t.Fatalf("Expected an empty jump to go back to the start but got %d", pb.Tick())
+	}
+}
+
+func TestPlaybackSeekStopsAtRecordedEnd(t *testing.T) {
+	pb, e := newDoublePlayback(t)
+
+	pb.Seek(e.ticks + 10)
+
+	if pb.Tick() != e.ticks {
+		t.Fatalf("Expected seek to stop at tick %d but got %d", e.ticks, pb.Tick())
+	}
+}
@@ -0,0 +1,143 @@
+package snake
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/imega/snake-game/state"
+)
+
+type powerUp int
+
+// Power-ups a snake gets for a number of ticks from the food that carries them
+const (
+	noPower powerUp = iota
+	boost
+	slowMotion
+	invincible
+	ghost
+	multiplier
+	powerUpCount
+)
+
+func (p powerUp) String() string {
+	switch p {
+	case boost:
+		return "boost"
+	case slowMotion:
+		return "slow-motion"
+	case invincible:
+		return "invincible"
+	case ghost:
+		return "ghost"
+	case multiplier:
+		return "multiplier"
+	default:
+		return ""
+	}
+}
+
+func parsePowerUp(s string) (powerUp, error) {
+	for p := boost; p < powerUpCount; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+
+	return noPower, fmt.Errorf("unknown power-up %q", s)
+}
+
+const (
+	// boostPace is how many milliseconds a boost takes off a tick and slow
+	// motion adds to it
+	boostPace = 30
+	// scoreMultiplier is how many times more food is worth under the multiplier
+	scoreMultiplier = 2
+)
+
+// powerKind returns the food kind that carries the power-up
+func powerKind(p powerUp) *foodKind {
+	for i := range foodKinds {
+		if foodKinds[i].power == p {
+			return &foodKinds[i]
+		}
+	}
+
+	return nil
+}
+
+// give starts the power-up or restarts its count down
+func (s *snake) give(p powerUp, ticks int) {
+	if p > noPower && p < powerUpCount && ticks > s.powers[p] {
+		s.powers[p] = ticks
+	}
+}
+
+func (s *snake) has(p powerUp) bool {
+	return s.powers[p] > 0
+}
+
+// passesThrough tells whether the snake survives running into a body
+func (s *snake) passesThrough() bool {
+	return s.has(ghost) || s.has(invincible)
+}
+
+// wear counts down the power-ups after a move
+func (s *snake) wear() {
+	for p := range s.powers {
+		if s.powers[p] > 0 {
+			s.powers[p]--
+		}
+	}
+}
+
+// activePowers returns the power-ups of the snake in catalogue order
+func (s *snake) activePowers() []state.PowerUp {
+	var ps []state.PowerUp
+
+	for p := boost; p < powerUpCount; p++ {
+		if s.has(p) {
+			ps = append(ps, state.PowerUp{Name: p.String(), Ticks: s.powers[p]})
+		}
+	}
+
+	return ps
+}
+
+func restorePowers(s *snake, powers map[string]int) error {
+	names := make([]string, 0, len(powers))
+	for n := range powers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		p, err := parsePowerUp(n)
+		if err != nil {
+			return err
+		}
+
+		s.give(p, powers[n])
+	}
+
+	return nil
+}
+
+// powerPace is how many milliseconds the power-ups of the snakes take
+// off a tick
+func (e *Engine) powerPace() int {
+	var pace int
+
+	for _, s := range e.arena.players() {
+		if s.has(boost) {
+			pace += boostPace
+		}
+
+		if s.has(slowMotion) {
+			pace -= boostPace
+		}
+	}
+
+	return pace
+}
@@ -0,0 +1,92 @@
+package snake
+
+import (
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func TestInvincibleSnakeWrapsAroundArena(t *testing.T) {
+	a := newDoubleArena(10, 3)
+	a.snake.give(invincible, 5)
+
+	a.moveSnake()
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected invincible Snake to survive the wall but got %s", err)
+	}
+
+	if h := a.snake.head(); h.x != 0 || h.y != 4 {
+		t.Fatalf("Expected head to re-enter at [0 4] but got %v", h)
+	}
+}
+
+func TestInvincibleSnakeGoesThroughObstacles(t *testing.T) {
+	a := newDoubleArena(10, 10)
+	a.obstacles = []coord{{x: 2, y: 4}}
+	a.snake.give(invincible, 1)
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected invincible Snake to go through the obstacle but got %s", err)
+	}
+
+	if err := a.moveSnake(); err != nil {
+		t.Fatalf("Expected Snake to leave the obstacle but got %s", err)
+	}
+
+	if a.snake.has(invincible) {
+		t.Fatal("Expected invincibility to have worn off")
+	}
+}
+
+func TestGhostSnakePassesThroughItself(t *testing.T) {
+	for _, powers := range []int{0, 3} {
+		a := newDoubleArena(10, 10)
+		a.snake.body = []coord{{x: 1, y: 1}, {x: 2, y: 1}, {x: 2, y: 2}, {x: 1, y: 2}}
+		a.snake.length = 4
+		a.snake.direction = DOWN
+		a.snake.give(ghost, powers)
+
+		err := a.moveSnake()
+		if powers == 0 && deathCause(err) != DeathSelf {
+			t.Fatalf("Expected Snake to bite itself but got %v", err)
+		}
+
+		if powers > 0 && err != nil {
+			t.Fatalf("Expected ghost Snake to pass through itself but got %s", err)
+		}
+	}
+}
+
+func TestEngineStepGivesPowerUps(t *testing.T) {
+	e := newDoubleEngine(t)
+	e.arena.findFood = func(*arena, coord) int {
+		return 0
+	}
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, findFoodKind("gem"), 0, 0)
+	st, _, _ := e.Step(NOOP)
+
+	want := []state.PowerUp{{Name: "multiplier", Ticks: 50}}
+	if len(st.Snake.Powers) != 1 || st.Snake.Powers[0] != want[0] {
+		t.Fatalf("Expected %v but got %v", want, st.Snake.Powers)
+	}
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, findFoodKind("lightning"), 0, 0)
+	e.Step(NOOP)
+
+	if e.powerPace() != boostPace {
+		t.Fatalf("Expected lightning to speed the game up by %d but got %d", boostPace, e.powerPace())
+	}
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, findFoodKind("fruit"), 0, 0)
+	st, reward, _ := e.Step(NOOP)
+
+	if reward != 10*scoreMultiplier {
+		t.Fatalf("Expected fruit to be worth %d under the multiplier but got %d", 10*scoreMultiplier, reward)
+	}
+
+	if len(st.Snake.Powers) != 2 || st.Snake.Powers[1].Ticks != 48 {
+		t.Fatalf("Expected the multiplier to have worn down to 48 but got %v", st.Snake.Powers)
+	}
+}
@@ -2,6 +2,8 @@ package snake
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/imega/snake-game/state"
 	"github.com/mattn/go-runewidth"
@@ -12,6 +14,8 @@ const (
 	defaultColor = termbox.ColorDefault
 	bgColor      = termbox.ColorDefault
 	snakeColor   = termbox.ColorGreen
+	rivalColor   = termbox.ColorBlue
+	wallColor    = termbox.ColorWhite
 )
 
 func (g *Game) render(p state.Parameters, stat state.Stat) error {
@@ -21,39 +25,105 @@ func (g *Game) render(p state.Parameters, stat state.Stat) error {
 
 	termbox.Clear(defaultColor, defaultColor)
 
+	w, h := termbox.Size()
+	if err := fitsTerminal(g.arena, w, h); err != nil {
+		return err
+	}
+
 	var (
-		w, h   = termbox.Size()
-		midY   = h / 2
-		left   = (w - g.arena.width) / 2
-		right  = (w + g.arena.width) / 2
-		top    = midY - (g.arena.height / 2)
-		bottom = midY + (g.arena.height / 2) + 1
+		left   = (w-g.arena.width-2)/2 + 1
+		right  = left + g.arena.width
+		top    = (h-g.arena.height-4)/2 + 1
+		bottom = top + g.arena.height + 1
 	)
 
-	renderTitle(p, left, top, g.arena, stat)
+	switch {
+	case g.notice != "" && time.Now().Before(g.noticeUntil):
+		tbprint(left, top-1, defaultColor, defaultColor, g.notice)
+	case g.title != "":
+		tbprint(left, top-1, defaultColor, defaultColor, g.title)
+	default:
+		renderTitle(p, left, top, g.arena, stat)
+	}
 	renderArena(g.arena, top, bottom, left)
-	renderSnake(left, bottom, g.arena.snake)
-	renderFood(left, bottom, g.arena.food)
-	renderScore(left, bottom, g.score)
-	renderQuitMessage(right, bottom)
+	renderObstacles(left, bottom, g.arena.obstacles)
+	renderSnake(left, bottom, g.arena.snake, snakeColor)
+	if g.arena.rival != nil {
+		renderSnake(left, bottom, g.arena.rival, rivalColor)
+	}
+	renderFood(left, bottom, g.arena.foods)
+	w = renderScore(left, bottom, g.score, g.breakdown(), g.modeStatus())
+	if g.arena.rival != nil {
+		w = renderDuelScore(left, bottom, g.score, g.rivalScore, g.isOver, g.winner, g.modeStatus())
+	}
+
+	x := renderPowers(left+w+2, right-18, bottom, g.arena.snake, snakeColor)
+	if g.arena.rival != nil {
+		x = renderPowers(x, right-18, bottom, g.arena.rival, rivalColor)
+	}
+
+	renderFoodTimers(x, right-18, bottom, g.arena.foods)
+	renderQuitMessage(right, bottom, g.keymap.hint(actionQuit))
+
+	switch {
+	case g.paused:
+		renderPaused(left, top, bottom, g.arena.width, pauseHint(g.keymap))
+	case g.isOver:
+		renderGameOver(left, top, bottom, g.arena.width, g.outcome(), deathMessage(g.deaths, g.arena.rival != nil), g.scoreLines())
+	}
 
 	return termbox.Flush()
 }
 
-func renderSnake(left, bottom int, s *snake) {
+// fitsTerminal checks there is room for the arena with its borders,
+// the title above and the score below
+func fitsTerminal(a *arena, w, h int) error {
+	if w < a.width+2 || h < a.height+4 {
+		return fmt.Errorf(
+			"terminal %dx%d is too small for a %dx%d arena, need at least %dx%d",
+			w, h, a.width, a.height, a.width+2, a.height+4,
+		)
+	}
+
+	return nil
+}
+
+func renderObstacles(left, bottom int, obstacles []coord) {
+	for _, o := range obstacles {
+		termbox.SetCell(left+o.x, bottom-1-o.y, ' ', wallColor, wallColor)
+	}
+}
+
+func renderSnake(left, bottom int, s *snake, color termbox.Attribute) {
+	ch, fg, bg := ' ', color, color
+
+	switch {
+	case s.has(invincible):
+		fg, bg = termbox.ColorYellow, termbox.ColorYellow
+	case s.has(ghost):
+		ch, bg = '░', bgColor
+	}
+
 	for _, b := range s.body {
-		termbox.SetCell(left+b.x, bottom-b.y, ' ', snakeColor, snakeColor)
+		termbox.SetCell(left+b.x, bottom-1-b.y, ch, fg, bg)
 	}
 }
 
-func renderFood(left, bottom int, f *food) {
-	termbox.SetCell(left+f.x, bottom-f.y, f.emoji, defaultColor, bgColor)
+func renderFood(left, bottom int, foods []*food) {
+	for _, f := range foods {
+		termbox.SetCell(left+f.x, bottom-1-f.y, f.emoji, f.kind.color, bgColor)
+	}
 }
 
 func renderArena(a *arena, top, bottom, left int) {
+	vertical, horizontal := '│', '─'
+	if a.wrap {
+		vertical, horizontal = '┆', '┄'
+	}
+
 	for i := top; i < bottom; i++ {
-		termbox.SetCell(left-1, i, '│', defaultColor, bgColor)
-		termbox.SetCell(left+a.width, i, '│', defaultColor, bgColor)
+		termbox.SetCell(left-1, i, vertical, defaultColor, bgColor)
+		termbox.SetCell(left+a.width, i, vertical, defaultColor, bgColor)
 	}
 
 	termbox.SetCell(left-1, top, '┌', defaultColor, bgColor)
@@ -61,22 +131,188 @@ func renderArena(a *arena, top, bottom, left int) {
 	termbox.SetCell(left+a.width, top, '┐', defaultColor, bgColor)
 	termbox.SetCell(left+a.width, bottom, '┘', defaultColor, bgColor)
 
-	fill(left, top, a.width, 1, termbox.Cell{Ch: '─'})
-	fill(left, bottom, a.width, 1, termbox.Cell{Ch: '─'})
+	fill(left, top, a.width, 1, termbox.Cell{Ch: horizontal})
+	fill(left, bottom, a.width, 1, termbox.Cell{Ch: horizontal})
 }
 
-func renderScore(left, bottom, s int) {
+func renderScore(left, bottom, s int, breakdown, status string) int {
 	score := fmt.Sprintf("Score: %v", s)
+	if breakdown != "" {
+		score += " " + breakdown
+	}
+
+	if status != "" {
+		score += "  " + status
+	}
+
 	tbprint(left, bottom+1, defaultColor, defaultColor, score)
+
+	return len(score)
 }
 
-func renderQuitMessage(right, bottom int) {
-	m := "Press ESC to quit"
-	tbprint(right-17, bottom+1, defaultColor, defaultColor, m)
+func renderDuelScore(left, bottom, s1, s2 int, isOver bool, winner int, status string) int {
+	p1 := fmt.Sprintf("P1: %v", s1)
+	p2 := fmt.Sprintf("P2: %v", s2)
+
+	tbprint(left, bottom+1, snakeColor, defaultColor, p1)
+	tbprint(left+len(p1)+2, bottom+1, rivalColor, defaultColor, p2)
+
+	w := len(p1) + 2 + len(p2)
+
+	if isOver {
+		result := "Draw"
+		if winner > 0 {
+			result = fmt.Sprintf("P%d wins", winner)
+		}
+
+		tbprint(left+w+2, bottom+1, defaultColor, defaultColor, result)
+		w += 2 + len(result)
+	}
+
+	if status != "" {
+		tbprint(left+w+2, bottom+1, defaultColor, defaultColor, status)
+		w += 2 + len(status)
+	}
+
+	return w
+}
+
+func renderFoodTimers(x, limit, bottom int, foods []*food) {
+	for _, f := range foods {
+		if f.ttl == 0 {
+			continue
+		}
+
+		if x+6 > limit {
+			return
+		}
+
+		termbox.SetCell(x, bottom+1, f.emoji, f.kind.color, bgColor)
+		x += runewidth.RuneWidth(f.emoji)
+
+		t := fmt.Sprintf("%-4d", f.ttl)
+		tbprint(x, bottom+1, f.kind.color, bgColor, t)
+		x += len(t)
+	}
+}
+
+// renderPowers shows the power-ups of the snake with the ticks they have
+// left and returns where the next indicator goes
+func renderPowers(x, limit, bottom int, s *snake, color termbox.Attribute) int {
+	for p := boost; p < powerUpCount; p++ {
+		k := powerKind(p)
+		if !s.has(p) || k == nil {
+			continue
+		}
+
+		if x+6 > limit {
+			return x
+		}
+
+		g := k.glyph
+		if hasUnicodeSupport() {
+			g = k.emojis[0]
+		}
+
+		termbox.SetCell(x, bottom+1, g, k.color, bgColor)
+		x += runewidth.RuneWidth(g)
+
+		t := fmt.Sprintf("%-4d", s.powers[p])
+		tbprint(x, bottom+1, color, bgColor, t)
+		x += len(t)
+	}
+
+	return x
+}
+
+func renderQuitMessage(right, bottom int, key string) {
+	if key == "" {
+		return
+	}
+
+	m := "Press " + key + " to quit"
+	tbprint(right-runewidth.StringWidth(m), bottom+1, defaultColor, defaultColor, m)
+}
+
+// pauseHint names the keys that resume and step a paused game
+func pauseHint(km *Keymap) string {
+	var parts []string
+	if k := km.hint(actionPause); k != "" {
+		parts = append(parts, k+" resume")
+	}
+
+	if k := km.hint(actionStep); k != "" {
+		parts = append(parts, k+" step")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func renderPaused(left, top, bottom, width int, hint string) {
+	m := "PAUSED"
+	if hint != "" && runewidth.StringWidth(m+"  "+hint) <= width {
+		m += "  " + hint
+	}
+
+	tbprint(left+(width-runewidth.StringWidth(m))/2, (top+bottom)/2, termbox.ColorBlack, termbox.ColorWhite, m)
+}
+
+func renderGameOver(left, top, bottom, width int, outcome, cause string, scores []string) {
+	lines := []string{cause}
+	if len(scores) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, scores...)
+	}
+
+	y := (top+bottom)/2 - len(lines)/2
+	if y <= top {
+		y = top + 1
+	}
+
+	tbprint(left+(width-len(outcome))/2, y, termbox.ColorBlack, termbox.ColorWhite, outcome)
+
+	for _, l := range lines {
+		y++
+		if y >= bottom {
+			return
+		}
+
+		if l != "" && len(l) <= width {
+			tbprint(left+(width-len(l))/2, y, defaultColor, defaultColor, l)
+		}
+	}
+}
+
+// deathMessage tells how the snakes died, naming the players in a duel
+func deathMessage(deaths [2]DeathCause, duel bool) string {
+	if !duel {
+		if deaths[0] == NoDeath {
+			return ""
+		}
+
+		return "Snake " + deaths[0].describe()
+	}
+
+	var parts []string
+	for i, d := range deaths {
+		if d != NoDeath {
+			parts = append(parts, fmt.Sprintf("P%d %s", i+1, d.describe()))
+		}
+	}
+
+	return strings.Join(parts, ", ")
 }
 
 func renderTitle(p state.Parameters, left, top int, a *arena, s state.Stat) {
 	msg := "Snake Game in human mode"
+	if p.TwoPlayers {
+		msg = "Snake Game in two player mode, arrows against WASD"
+	}
+
+	if p.Versus {
+		msg = "Snake Game, arrows against the AI"
+	}
+
 	if !p.Human {
 		msg = fmt.Sprintf(
 			"Snake Game MaxScore: %d, epoch: %d, epochMaxScore: %d, inst: %d",
@@ -86,6 +322,14 @@ func renderTitle(p state.Parameters, left, top int, a *arena, s state.Stat) {
 			s.Instance,
 		)
 	}
+
+	if p.Watch {
+		msg = "Snake Game, watching the AI"
+	}
+
+	if m := modeTitle(p); m != "" {
+		msg += ", " + m
+	}
 	tbprint(left, top-1, defaultColor, defaultColor, msg)
 }
 
@@ -0,0 +1,158 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/imega/snake-game/state"
+)
+
+// replayVersion is bumped whenever the rules change the way a replay plays back
+const replayVersion = 8
+
+// Replay is a recorded game. The seed and the parameters rebuild the arena,
+// the turns are the direction changes of the snakes tick by tick.
+type Replay struct {
+	Version    int              `json:"version"`
+	Seed       int64            `json:"seed"`
+	Parameters state.Parameters `json:"parameters"`
+	Turns      []Turn           `json:"turns"`
+	Speeds     []SpeedChange    `json:"speeds,omitempty"`
+	Ticks      int              `json:"ticks"`
+	Score      int              `json:"score"`
+	RivalScore int              `json:"rival_score,omitempty"`
+	Winner     int              `json:"winner,omitempty"`
+}
+
+// Turn is a snake taking a new direction right before the tick moves it,
+// player 1 is the first snake and player 2 the second one
+type Turn struct {
+	Tick      int    `json:"t"`
+	Player    int    `json:"p"`
+	Direction string `json:"d"`
+}
+
+// SpeedChange is the speed of the game changed right before the tick,
+// in milliseconds per tick
+type SpeedChange struct {
+	Tick  int `json:"t"`
+	Speed int `json:"s"`
+}
+
+// Replay returns the recording of the current game
+func (e *Engine) Replay() *Replay {
+	turns := make([]Turn, len(e.turns))
+	copy(turns, e.turns)
+
+	var speeds []SpeedChange
+	if len(e.speeds) > 0 {
+		speeds = append(speeds, e.speeds...)
+	}
+
+	return &Replay{
+		Version:    replayVersion,
+		Seed:       e.seed,
+		Parameters: e.params,
+		Turns:      turns,
+		Speeds:     speeds,
+		Ticks:      e.ticks,
+		Score:      e.score,
+		RivalScore: e.rivalScore,
+		Winner:     e.winner,
+	}
+}
+
+// record remembers the directions the snakes have moved in during the tick
+func (e *Engine) record() {
+	for i, s := range e.arena.players() {
+		if s.direction == e.directions[i] {
+			continue
+		}
+
+		e.directions[i] = s.direction
+		e.turns = append(e.turns, Turn{
+			Tick:      e.ticks,
+			Player:    i + 1,
+			Direction: s.direction.String(),
+		})
+	}
+}
+
+// Save writes the replay as <dir>/replay-<score>-<seed>.json and returns the
+// path, a number is added to the name when a replay of that name exists
+func (r *Replay) Save(dir string) (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode replay, %s", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create replay directory, %s", err)
+	}
+
+	base := fmt.Sprintf("replay-%d-%d", r.Score, r.Seed)
+	name := filepath.Join(dir, base+".json")
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	for n := 2; os.IsExist(err); n++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s-%d.json", base, n))
+		f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to create replay, %s", err)
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write replay, %s", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to close replay, %s", err)
+	}
+
+	return name, nil
+}
+
+// LoadReplay reads and validates a replay file
+func LoadReplay(filename string) (*Replay, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay, %s", err)
+	}
+
+	var r Replay
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse replay %s, %s", filename, err)
+	}
+
+	if r.Version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", r.Version)
+	}
+
+	if err := validateParameters(r.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid replay parameters, %s", err)
+	}
+
+	for _, t := range r.Turns {
+		if _, err := parseDirection(t.Direction); err != nil || t.Tick < 0 || t.Tick >= r.Ticks {
+			return nil, fmt.Errorf("invalid turn %+v", t)
+		}
+
+		if t.Player < 1 || t.Player > 2 || (t.Player == 2 && !r.Parameters.TwoPlayers) {
+			return nil, fmt.Errorf("invalid turn %+v", t)
+		}
+	}
+
+	for _, s := range r.Speeds {
+		if s.Tick < 0 || s.Tick >= r.Ticks || s.Speed < 0 {
+			return nil, fmt.Errorf("invalid speed change %+v", s)
+		}
+	}
+
+	return &r, nil
+}
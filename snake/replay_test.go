@@ -0,0 +1,150 @@
+package snake
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func playRecordedGame(e *Engine, actions []Action) {
+	e.Reset(7)
+
+	for _, a := range actions {
+		e.Step(a)
+	}
+
+	for i := 0; i < 100 && !e.isOver; i++ {
+		e.Step(NOOP)
+	}
+}
+
+func TestEngineRecordsTurns(t *testing.T) {
+	e := newDoubleEngine(t)
+	playRecordedGame(e, []Action{NOOP, MoveUp, MoveUp, MoveRight, MoveLeft, MoveDown})
+
+	r := e.Replay()
+	want := []Turn{
+		{Tick: 1, Player: 1, Direction: "up"},
+		{Tick: 3, Player: 1, Direction: "right"},
+		{Tick: 5, Player: 1, Direction: "down"},
+	}
+
+	if !reflect.DeepEqual(r.Turns, want) {
+		t.Fatalf("Expected turns %v but got %v", want, r.Turns)
+	}
+
+	if r.Seed != 7 || r.Ticks != e.ticks || r.Version != replayVersion {
+		t.Fatalf("Unexpected replay header %+v", r)
+	}
+}
+
+func TestReplayReproducesGame(t *testing.T) {
+	e := newDoubleEngine(t)
+	playRecordedGame(e, []Action{MoveUp, NOOP, NOOP, MoveRight, NOOP, MoveDown})
+
+	r := e.Replay()
+	want := e.State()
+
+	e2, err := NewEngine(r.Parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e2.Reset(r.Seed)
+
+	for tick := 0; tick < r.Ticks; tick++ {
+		a := NOOP
+		for _, turn := range r.Turns {
+			if turn.Tick == tick {
+				d, _ := parseDirection(turn.Direction)
+				a = directionAction(d)
+			}
+		}
+
+		e2.Step(a)
+	}
+
+	if got := e2.State(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected replay to end in %+v but got %+v", want, got)
+	}
+}
+
+func directionAction(d direction) Action {
+	switch d {
+	case RIGHT:
+		return MoveRight
+	case LEFT:
+		return MoveLeft
+	case UP:
+		return MoveUp
+	default:
+		return MoveDown
+	}
+}
+
+func TestReplaySaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := newDoubleEngine(t)
+	playRecordedGame(e, []Action{MoveUp})
+
+	name, err := e.Replay().Save(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := LoadReplay(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(r, e.Replay()) {
+		t.Fatalf("Expected loaded replay %+v to match %+v", r, e.Replay())
+	}
+}
+
+func TestReplaySaveKeepsEarlierReplays(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := newDoubleEngine(t)
+	playRecordedGame(e, []Action{MoveUp})
+
+	names := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		name, err := e.Replay().Save(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names[filepath.Base(name)] = true
+	}
+
+	for _, want := range []string{"replay-0-7.json", "replay-0-7-2.json", "replay-0-7-3.json"} {
+		if !names[want] {
+			t.Fatalf("Expected %s among the replays but got %v", want, names)
+		}
+	}
+}
+
+func TestRetryStartsReplayableGame(t *testing.T) {
+	e1 := newDoubleEngine(t)
+	e1.Reset(3)
+	e1.retry()
+
+	e2 := newDoubleEngine(t)
+	e2.Reset(e1.seed)
+
+	if !reflect.DeepEqual(e1.State(), e2.State()) {
+		t.Fatal("Expected a retried game to start like a game reset with its seed")
+	}
+}
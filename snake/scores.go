@@ -0,0 +1,275 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/imega/snake-game/state"
+)
+
+const (
+	// scoresVersion is bumped whenever the score file format changes
+	scoresVersion = 1
+	// maxScores is how many entries are kept for each table
+	maxScores = 10
+	// scoresDir is the directory of the game in the data directory
+	scoresDir = "snake-game"
+)
+
+// ScoreEntry is a finished game in the high-score table
+type ScoreEntry struct {
+	Player string    `json:"player"`
+	Mode   string    `json:"mode"`
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	Score  int       `json:"score"`
+	Length int       `json:"length"`
+	Ticks  int       `json:"ticks"`
+	Death  string    `json:"death,omitempty"`
+	Date   time.Time `json:"date"`
+}
+
+// table is the key of the high-score table the entry belongs to
+func (s ScoreEntry) table() string {
+	return fmt.Sprintf("%s %dx%d %s", s.Mode, s.Width, s.Height, s.Player)
+}
+
+// Scores is the high-score store, a table of the best games for every
+// mode, arena size and player
+type Scores struct {
+	Version int          `json:"version"`
+	Entries []ScoreEntry `json:"entries"`
+}
+
+// ScoresFile returns the high-score file in the XDG data directory,
+// $XDG_DATA_HOME or ~/.local/share
+func ScoresFile() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the data directory, %s", err)
+		}
+
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dir, scoresDir, "scores.json"), nil
+}
+
+// LoadScores reads the high-score file, a missing file is an empty store
+func LoadScores(filename string) (*Scores, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &Scores{Version: scoresVersion}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scores, %s", err)
+	}
+
+	var s Scores
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scores %s, %s", filename, err)
+	}
+
+	if s.Version != scoresVersion {
+		return nil, fmt.Errorf("unsupported scores version %d", s.Version)
+	}
+
+	return &s, nil
+}
+
+// Save writes the high-score file, creating its directory
+func (s *Scores) Save(filename string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scores, %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create scores directory, %s", err)
+	}
+
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return fmt.Errorf("failed to write scores, %s", err)
+	}
+
+	return nil
+}
+
+// Add puts the entry in its table and returns its rank from 1, or 0 when
+// it is not good enough to be kept
+func (s *Scores) Add(e ScoreEntry) int {
+	s.Entries = append(s.Entries, e)
+	s.sort()
+
+	var (
+		kept  []ScoreEntry
+		count = make(map[string]int)
+		rank  int
+	)
+
+	for i := range s.Entries {
+		t := s.Entries[i].table()
+		if count[t] == maxScores {
+			continue
+		}
+
+		count[t]++
+		kept = append(kept, s.Entries[i])
+
+		if s.Entries[i] == e {
+			rank = count[t]
+		}
+	}
+
+	s.Entries = kept
+
+	return rank
+}
+
+// Table returns the entries of the table of the mode, arena size and
+// player from the best one
+func (s *Scores) Table(mode string, width, height int, player string) []ScoreEntry {
+	key := ScoreEntry{Mode: mode, Width: width, Height: height, Player: player}.table()
+
+	var t []ScoreEntry
+	for _, e := range s.Entries {
+		if e.table() == key {
+			t = append(t, e)
+		}
+	}
+
+	return t
+}
+
+// Tables returns every high-score table, each from the best entry
+func (s *Scores) Tables() [][]ScoreEntry {
+	s.sort()
+
+	var tables [][]ScoreEntry
+	for i, e := range s.Entries {
+		if i == 0 || e.table() != s.Entries[i-1].table() {
+			tables = append(tables, nil)
+		}
+
+		tables[len(tables)-1] = append(tables[len(tables)-1], e)
+	}
+
+	return tables
+}
+
+// sort orders the entries by table, then from the best one, older
+// entries first among equal results
+func (s *Scores) sort() {
+	sort.SliceStable(s.Entries, func(i, j int) bool {
+		a, b := s.Entries[i], s.Entries[j]
+		if a.table() != b.table() {
+			return a.table() < b.table()
+		}
+
+		ra, rb := a.result(), b.result()
+		if ra != rb {
+			return betterResult(a.Mode, ra, rb)
+		}
+
+		return a.Date.Before(b.Date)
+	})
+}
+
+// result is what the entry counts for in its mode
+func (s ScoreEntry) result() int {
+	if s.Mode == ModeTarget {
+		return s.Ticks
+	}
+
+	return s.Score
+}
+
+// scoreEntry describes the finished game of the first snake
+func (e *Engine) scoreEntry(date time.Time) ScoreEntry {
+	return ScoreEntry{
+		Player: e.params.Player,
+		Mode:   gameMode(e.params),
+		Width:  e.params.Width,
+		Height: e.params.Height,
+		Score:  e.score,
+		Length: e.arena.snake.length,
+		Ticks:  e.ticks,
+		Death:  e.deaths[0].String(),
+		Date:   date,
+	}
+}
+
+// keepsScores tells whether the games of the parameters go into the
+// high-score table, only games of a single human player do
+func keepsScores(p state.Parameters) bool {
+	return p.Human && !p.TwoPlayers && p.Player != ""
+}
+
+// saveScore adds the finished game to the high-score table and keeps the
+// table to show on the game-over screen
+func (g *Game) saveScore() {
+	g.rank, g.highScores = 0, nil
+
+	if !keepsScores(g.params) {
+		return
+	}
+
+	if _, ok := g.result(); !ok {
+		return
+	}
+
+	file := g.params.Scores
+	if file == "" {
+		f, err := ScoresFile()
+		if err != nil {
+			g.notify(err.Error())
+			return
+		}
+
+		file = f
+	}
+
+	s, err := LoadScores(file)
+	if err != nil {
+		g.notify(err.Error())
+		return
+	}
+
+	e := g.scoreEntry(time.Now())
+	g.rank = s.Add(e)
+	g.highScores = s.Table(e.Mode, e.Width, e.Height, e.Player)
+
+	if err := s.Save(file); err != nil {
+		g.notify(err.Error())
+	}
+}
+
+// scoreLines are the rows of the high-score table on the game-over
+// screen, the game just played is marked
+func (g *Game) scoreLines() []string {
+	lines := make([]string, 0, len(g.highScores))
+
+	for i, e := range g.highScores {
+		mark := " "
+		if i+1 == g.rank {
+			mark = ">"
+		}
+
+		result := fmt.Sprintf("%5d", e.Score)
+		if e.Mode == ModeTarget {
+			result = fmt.Sprintf("%5dt", e.Ticks)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s%2d. %-8.8s %s  %s", mark, i+1, e.Player, result, e.Date.Format("2006-01-02")))
+	}
+
+	return lines
+}
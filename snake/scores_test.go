@@ -0,0 +1,122 @@
+package snake
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newDoubleScoreEntry(player string, score int) ScoreEntry {
+	return ScoreEntry{
+		Player: player,
+		Mode:   ModeEndless,
+		Width:  50,
+		Height: 20,
+		Score:  score,
+		Date:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestScoresKeepTheBestOfEachTable(t *testing.T) {
+	s := &Scores{Version: scoresVersion}
+
+	for i := 1; i <= maxScores; i++ {
+		s.Add(newDoubleScoreEntry("ann", i*10))
+	}
+
+	if r := s.Add(newDoubleScoreEntry("ann", 5)); r != 0 {
+		t.Fatalf("Expected a score below the table not to be kept but got rank %d", r)
+	}
+
+	if r := s.Add(newDoubleScoreEntry("ann", 55)); r != 6 {
+		t.Fatalf("Expected 55 to rank 6 but got %d", r)
+	}
+
+	if r := s.Add(newDoubleScoreEntry("bob", 5)); r != 1 {
+		t.Fatalf("Expected another player to have a table of their own but got rank %d", r)
+	}
+
+	ann := s.Table(ModeEndless, 50, 20, "ann")
+	if len(ann) != maxScores || ann[0].Score != 100 || ann[maxScores-1].Score != 20 {
+		t.Fatalf("Expected the best %d scores from 100 to 20 but got %v", maxScores, ann)
+	}
+
+	if n := len(s.Tables()); n != 2 {
+		t.Fatalf("Expected 2 tables but got %d", n)
+	}
+}
+
+func TestScoresRankTargetModeByTicks(t *testing.T) {
+	s := &Scores{Version: scoresVersion}
+
+	for _, ticks := range []int{300, 200, 250} {
+		e := newDoubleScoreEntry("ann", 200)
+		e.Mode = ModeTarget
+		e.Ticks = ticks
+		s.Add(e)
+	}
+
+	tt := s.Table(ModeTarget, 50, 20, "ann")
+	if tt[0].Ticks != 200 || tt[2].Ticks != 300 {
+		t.Fatalf("Expected fewest ticks first but got %v", tt)
+	}
+}
+
+func TestScoresSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("XDG_DATA_HOME", dir)
+	defer os.Unsetenv("XDG_DATA_HOME")
+
+	name, err := ScoresFile()
+	if err != nil || name != filepath.Join(dir, "snake-game", "scores.json") {
+		t.Fatalf("Expected the scores in the XDG data directory but got %s, %v", name, err)
+	}
+
+	s, err := LoadScores(name)
+	if err != nil || len(s.Entries) != 0 {
+		t.Fatalf("Expected a missing file to be an empty store but got %v, %v", s, err)
+	}
+
+	p := newDoubleParameters()
+	p.Human = true
+	p.Player = "ann"
+	p.Scores = name
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Game{Engine: e}
+	g.score = 30
+	g.Step(MoveDown)
+	for !g.isOver {
+		g.Step(NOOP)
+	}
+
+	g.saveScore()
+
+	if g.rank != 1 || len(g.scoreLines()) != 1 {
+		t.Fatalf("Expected the game to top the table but got rank %d", g.rank)
+	}
+
+	s, err = LoadScores(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ScoreEntry{Player: "ann", Mode: ModeEndless, Width: 50, Height: 20, Score: 30, Length: 4, Ticks: 2, Death: "wall"}
+	got := s.Entries[0]
+	got.Date = time.Time{}
+
+	if len(s.Entries) != 1 || got != want {
+		t.Fatalf("Expected %+v but got %+v", want, s.Entries)
+	}
+}
@@ -0,0 +1,204 @@
+package snake
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imega/snake-game/state"
+)
+
+// Scoring rules that can be turned on next to the points of the food
+const (
+	// ScoreCombo rewards food eaten in quick succession
+	ScoreCombo = "combo"
+	// ScoreLength multiplies the points of the food by the snake length
+	ScoreLength = "length"
+	// ScoreSpeed multiplies the points of the food by the game speed
+	ScoreSpeed = "speed"
+	// ScoreWaste takes points for the steps wasted without eating
+	ScoreWaste = "waste"
+)
+
+// scoreBase is the part of the points that comes from the food itself
+const scoreBase = "base"
+
+const (
+	// comboWindow is how many steps a meal may follow the previous one
+	// and still extend the combo
+	comboWindow = 15
+	// comboBonus is how many points each meal of a combo adds after the first
+	comboBonus = 5
+	// lengthStep is how many segments add a tenth to the points of the food
+	lengthStep = 5
+	// wastePenalty is how many points a wasted step costs
+	wastePenalty = 1
+)
+
+// scoreRule turns the food points of a tick, 0 when nothing was eaten,
+// into the extra points of the rule
+type scoreRule struct {
+	name  string
+	apply func(e *Engine, s *snake, base int) int
+}
+
+var scoreRules = []scoreRule{
+	{name: ScoreCombo, apply: comboPoints},
+	{name: ScoreLength, apply: lengthPoints},
+	{name: ScoreSpeed, apply: speedPoints},
+	{name: ScoreWaste, apply: wastePoints},
+}
+
+// ScoreRules returns the names of the scoring rules
+func ScoreRules() []string {
+	names := make([]string, 0, len(scoreRules))
+	for _, r := range scoreRules {
+		names = append(names, r.name)
+	}
+
+	return names
+}
+
+func validateScoring(p state.Parameters) error {
+	for _, n := range p.Scoring {
+		found := false
+		for _, r := range scoreRules {
+			found = found || r.name == n
+		}
+
+		if !found {
+			return fmt.Errorf("unknown scoring rule %q", n)
+		}
+	}
+
+	return nil
+}
+
+// comboPoints rewards every meal of a combo after the first one, food
+// worth no points keeps the combo going but does not pay
+func comboPoints(e *Engine, s *snake, base int) int {
+	if s.eaten == nil || base <= 0 || s.combo < 2 {
+		return 0
+	}
+
+	return comboBonus * (s.combo - 1)
+}
+
+// lengthPoints adds a tenth of the food points for every lengthStep segments
+func lengthPoints(e *Engine, s *snake, base int) int {
+	return base * (s.length / lengthStep) / 10
+}
+
+// speedPoints adds a hundredth of the food points for every millisecond the
+// tick is shorter than the speed of the game, slow motion takes them away
+func speedPoints(e *Engine, s *snake, base int) int {
+	return base * (e.speed - e.interval()) / 100
+}
+
+// wastePoints takes points for every step once the snake has gone longer
+// without eating than it takes to cross the arena
+func wastePoints(e *Engine, s *snake, base int) int {
+	if s.steps <= e.arena.width+e.arena.height {
+		return 0
+	}
+
+	return -wastePenalty
+}
+
+func (e *Engine) scoring(rule string) bool {
+	for _, n := range e.params.Scoring {
+		if n == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// points tallies the points the snake of the player made this tick and
+// returns those that count for its score, the score never goes below 0.
+// Every rule counts for the fitness, the rules that are not turned on
+// only count there.
+func (e *Engine) points(player int, s *snake, base, score int) int {
+	if e.tallies[player] == nil {
+		e.tallies[player] = make(map[string]int)
+	}
+
+	total := base
+	e.fitness[player] += base
+
+	parts := make([]state.ScorePart, 0, len(scoreRules))
+	for _, r := range scoreRules {
+		p := r.apply(e, s, base)
+		if p == 0 {
+			continue
+		}
+
+		e.fitness[player] += p
+
+		if e.scoring(r.name) {
+			total += p
+			parts = append(parts, state.ScorePart{Rule: r.name, Points: p})
+		}
+	}
+
+	// the penalties that would take the score below 0 are not counted, so
+	// the tallies keep adding up to the score
+	for i := len(parts) - 1; i >= 0 && score+total < 0; i-- {
+		if parts[i].Points >= 0 {
+			continue
+		}
+
+		cut := -(score + total)
+		if cut > -parts[i].Points {
+			cut = -parts[i].Points
+		}
+
+		parts[i].Points += cut
+		total += cut
+	}
+
+	e.tallies[player][scoreBase] += base
+	for _, p := range parts {
+		e.tallies[player][p.Rule] += p.Points
+	}
+
+	return total
+}
+
+// scoreParts is the score of the player by rule, food points first
+func (e *Engine) scoreParts(player int) []state.ScorePart {
+	if len(e.params.Scoring) == 0 {
+		return nil
+	}
+
+	parts := []state.ScorePart{{Rule: scoreBase, Points: e.tallies[player][scoreBase]}}
+	for _, r := range scoreRules {
+		if e.scoring(r.name) {
+			parts = append(parts, state.ScorePart{Rule: r.name, Points: e.tallies[player][r.name]})
+		}
+	}
+
+	return parts
+}
+
+// breakdown is the score of the first snake by rule for the score line
+func (e *Engine) breakdown() string {
+	parts := e.scoreParts(0)
+	if len(parts) == 0 {
+		return ""
+	}
+
+	s := make([]string, 0, len(parts))
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			s = append(s, fmt.Sprintf("%d %s", p.Points, p.Rule))
+		case p.Points < 0:
+			s = append(s, fmt.Sprintf("- %d %s", -p.Points, p.Rule))
+		default:
+			s = append(s, fmt.Sprintf("+ %d %s", p.Points, p.Rule))
+		}
+	}
+
+	return "(" + strings.Join(s, " ") + ")"
+}
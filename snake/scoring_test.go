@@ -0,0 +1,145 @@
+package snake
+
+import (
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func newDoubleScoringEngine(t *testing.T, rules ...string) *Engine {
+	p := newDoubleParameters()
+	p.Scoring = rules
+
+	e, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.arena.findFood = func(*arena, coord) int {
+		return 0
+	}
+
+	return e
+}
+
+func TestComboScoring(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreCombo)
+
+	rewards := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		_, r, _ := e.Step(NOOP)
+		rewards = append(rewards, r)
+	}
+
+	if rewards[0] != 10 || rewards[1] != 10+comboBonus || rewards[2] != 10+2*comboBonus {
+		t.Fatalf("Expected combo to add %d per meal but got %v", comboBonus, rewards)
+	}
+
+	e.arena.snake.steps = comboWindow + 1
+	if _, r, _ := e.Step(NOOP); r != 10 {
+		t.Fatalf("Expected a late meal to break the combo but got %d", r)
+	}
+}
+
+func TestLengthScoring(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreLength)
+	e.arena.snake.length = 2 * lengthStep
+
+	if _, r, _ := e.Step(NOOP); r != 12 {
+		t.Fatalf("Expected a snake of %d to make 12 points but got %d", 2*lengthStep, r)
+	}
+}
+
+func TestWasteScoringNeverGoesBelowZero(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreWaste)
+	e.arena.findFood = func(*arena, coord) int {
+		return -1
+	}
+
+	e.arena.snake.steps = e.arena.width + e.arena.height
+	e.score = 1
+
+	if _, r, _ := e.Step(NOOP); r != -wastePenalty || e.score != 0 {
+		t.Fatalf("Expected a wasted step to cost %d but got %d", wastePenalty, r)
+	}
+
+	if _, r, _ := e.Step(NOOP); r != 0 || e.score != 0 {
+		t.Fatalf("Expected the score to stay at 0 but got %d", e.score)
+	}
+
+	if w := e.tallies[0][ScoreWaste]; w != -wastePenalty {
+		t.Fatalf("Expected only the step that counted to be tallied but got %d", w)
+	}
+}
+
+func TestScoreBreakdownAddsUpToTheScore(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreWaste)
+	e.Step(NOOP)
+
+	e.arena.findFood = func(*arena, coord) int {
+		return -1
+	}
+
+	e.arena.snake.steps = e.arena.width + e.arena.height
+	for i := 0; i < 20; i++ {
+		e.Step(NOOP)
+	}
+
+	if b := e.breakdown(); e.score != 0 || b != "(10 base - 10 waste)" {
+		t.Fatalf("Expected the breakdown to add up to the score of %d but got %q", e.score, b)
+	}
+}
+
+func TestComboSkipsPowerUps(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreCombo)
+	e.Step(NOOP)
+
+	e.arena.foods[0] = newFoodOfKind(e.rnd, powerKind(ghost), 0, 0)
+	if _, r, _ := e.Step(NOOP); r != 0 {
+		t.Fatalf("Expected a power-up to pay no combo but got %d", r)
+	}
+
+	if _, r, _ := e.Step(NOOP); r != 10+comboBonus {
+		t.Fatalf("Expected the power-up to keep the combo going but got %d", r)
+	}
+}
+
+func TestFitnessCountsEveryRule(t *testing.T) {
+	e := newDoubleScoringEngine(t)
+
+	e.Step(NOOP)
+	st, _, _ := e.Step(NOOP)
+
+	// the second meal is a combo and the snake has grown to lengthStep
+	want := 20 + comboBonus + 2
+	if st.Score != 20 || st.Fitness != want || st.ScoreParts != nil {
+		t.Fatalf("Expected a flat score of 20 and a fitness of %d but got %d and %d", want, st.Score, st.Fitness)
+	}
+}
+
+func TestScoreBreakdown(t *testing.T) {
+	e := newDoubleScoringEngine(t, ScoreCombo, ScoreWaste)
+
+	e.Step(NOOP)
+	st, _, _ := e.Step(NOOP)
+
+	want := []state.ScorePart{{Rule: "base", Points: 20}, {Rule: "combo", Points: 5}, {Rule: "waste", Points: 0}}
+	for i, p := range want {
+		if i >= len(st.ScoreParts) || st.ScoreParts[i] != p {
+			t.Fatalf("Expected %v but got %v", want, st.ScoreParts)
+		}
+	}
+
+	if b := e.breakdown(); b != "(20 base + 5 combo + 0 waste)" {
+		t.Fatalf("Unexpected breakdown %q", b)
+	}
+}
+
+func TestNewEngineRejectsUnknownScoringRule(t *testing.T) {
+	p := newDoubleParameters()
+	p.Scoring = []string{"style"}
+
+	if _, err := NewEngine(p); err == nil {
+		t.Fatal("Expected unknown scoring rule to be rejected")
+	}
+}
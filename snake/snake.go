@@ -1,6 +1,13 @@
 package snake
 
-import "errors"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxQueuedTurns is how many key presses a snake remembers between ticks
+const maxQueuedTurns = 3
 
 // Allowed snake movement directions
 const (
@@ -12,11 +19,32 @@ const (
 
 type direction int
 
+func parseDirection(s string) (direction, error) {
+	switch strings.ToLower(s) {
+	case "right":
+		return RIGHT, nil
+	case "left":
+		return LEFT, nil
+	case "up":
+		return UP, nil
+	case "down":
+		return DOWN, nil
+	default:
+		return 0, fmt.Errorf("unknown direction %q", s)
+	}
+}
+
 type snake struct {
 	body      []coord
+	eaten     *food
 	direction direction
 	length    int
 	steps     int
+	combo     int
+	powers    [powerUpCount]int
+
+	mu    sync.Mutex
+	turns []direction
 }
 
 func newSnake(d direction, b []coord) *snake {
@@ -27,7 +55,7 @@ func newSnake(d direction, b []coord) *snake {
 	}
 }
 
-func (s *snake) changeDirection(d direction) {
+func (d direction) opposite() direction {
 	opposites := map[direction]direction{
 		RIGHT: LEFT,
 		LEFT:  RIGHT,
@@ -35,36 +63,76 @@ func (s *snake) changeDirection(d direction) {
 		DOWN:  UP,
 	}
 
-	if o := opposites[d]; o != 0 && o != s.direction {
+	return opposites[d]
+}
+
+func (d direction) String() string {
+	switch d {
+	case RIGHT:
+		return "right"
+	case LEFT:
+		return "left"
+	case UP:
+		return "up"
+	case DOWN:
+		return "down"
+	default:
+		return ""
+	}
+}
+
+func (s *snake) changeDirection(d direction) {
+	if o := d.opposite(); o != 0 && o != s.direction {
 		s.direction = d
 	}
 }
 
+// turn queues a direction change to be applied on a later tick, it is checked
+// against the last queued direction so quick presses cannot reverse the snake
+func (s *snake) turn(d direction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.direction
+	if n := len(s.turns); n > 0 {
+		last = s.turns[n-1]
+	}
+
+	if d.opposite() == 0 || d == last || d == last.opposite() || len(s.turns) >= maxQueuedTurns {
+		return
+	}
+
+	s.turns = append(s.turns, d)
+}
+
+// applyTurn takes the next queued direction change, one per tick
+func (s *snake) applyTurn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.turns) == 0 {
+		return
+	}
+
+	s.changeDirection(s.turns[0])
+	s.turns = s.turns[1:]
+}
+
 func (s *snake) head() coord {
 	return s.body[len(s.body)-1]
 }
 
-func (s *snake) die() error {
-	return errors.New("Died")
+func (s *snake) die(cause DeathCause) error {
+	return &DeathError{Cause: cause}
 }
 
 func (s *snake) move() error {
-	h := s.head()
-	c := coord{x: h.x, y: h.y}
-
-	switch s.direction {
-	case RIGHT:
-		c.x++
-	case LEFT:
-		c.x--
-	case UP:
-		c.y++
-	case DOWN:
-		c.y--
-	}
+	return s.moveTo(s.head().next(s.direction))
+}
 
-	if s.isOnPosition(c) {
-		return s.die()
+func (s *snake) moveTo(c coord) error {
+	if s.isOnPosition(c) && !s.passesThrough() {
+		return s.die(DeathSelf)
 	}
 
 	if s.length > len(s.body) {
@@ -76,6 +144,18 @@ func (s *snake) move() error {
 	return nil
 }
 
+// grow changes the snake length, a negative amount cuts the tail right away
+func (s *snake) grow(n int) {
+	s.length += n
+	if s.length < 1 {
+		s.length = 1
+	}
+
+	if len(s.body) > s.length {
+		s.body = s.body[len(s.body)-s.length:]
+	}
+}
+
 func (s *snake) isOnPosition(c coord) bool {
 	for _, b := range s.body {
 		if b.x == c.x && b.y == c.y {
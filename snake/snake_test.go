@@ -103,7 +103,7 @@ func TestChangeDirectionToInvalidDirection(t *testing.T) {
 func TestSnakeDie(t *testing.T) {
 	snake := newDoubleSnake(RIGHT)
 
-	if err := snake.die(); err.Error() != "Died" {
+	if err := snake.die(DeathWall); err == nil || deathCause(err) != DeathWall {
 		t.Fatal("Expected Snake die() to return error")
 	}
 }
@@ -117,7 +117,69 @@ func TestSnakeDieWhenMoveOnTopOfItself(t *testing.T) {
 
 	snake.changeDirection(LEFT)
 
-	if err := snake.die(); err.Error() != "Died" {
+	if err := snake.die(DeathSelf); err.Error() != "Died, bit itself" {
 		t.Fatal("Expected Snake to die when moved on top of itself")
 	}
 }
+
+func TestParseDirection(t *testing.T) {
+	if d, err := parseDirection("Up"); err != nil || d != UP {
+		t.Fatalf("Expected direction to be UP but got %v, %v", d, err)
+	}
+
+	if _, err := parseDirection("north"); err == nil {
+		t.Fatal("Expected unknown direction to be rejected")
+	}
+}
+
+func TestSnakeGrowNeverBelowOneSegment(t *testing.T) {
+	snake := newDoubleSnake(RIGHT)
+	snake.grow(-10)
+
+	if snake.length != 1 || len(snake.body) != 1 || snake.head().y != 4 {
+		t.Fatalf("Expected only the head to be left but got %v", snake.body)
+	}
+}
+
+func TestTurnIsAppliedOnePerTick(t *testing.T) {
+	snake := newDoubleSnake(RIGHT)
+	snake.turn(UP)
+	snake.turn(LEFT)
+
+	if snake.direction != RIGHT {
+		t.Fatal("Expected queued turns not to change direction before the tick")
+	}
+
+	snake.applyTurn()
+	if snake.direction != UP {
+		t.Fatalf("Expected first tick to turn UP but got %v", snake.direction)
+	}
+
+	snake.applyTurn()
+	if snake.direction != LEFT {
+		t.Fatalf("Expected second tick to turn LEFT but got %v", snake.direction)
+	}
+}
+
+func TestTurnIsCheckedAgainstLastQueued(t *testing.T) {
+	snake := newDoubleSnake(RIGHT)
+	snake.turn(UP)
+	snake.turn(DOWN)
+	snake.turn(UP)
+	snake.turn(5)
+
+	if len(snake.turns) != 1 || snake.turns[0] != UP {
+		t.Fatalf("Expected only UP to be queued but got %v", snake.turns)
+	}
+}
+
+func TestTurnQueueIsBounded(t *testing.T) {
+	snake := newDoubleSnake(RIGHT)
+	for _, d := range []direction{UP, LEFT, DOWN, RIGHT, UP} {
+		snake.turn(d)
+	}
+
+	if len(snake.turns) != maxQueuedTurns {
+		t.Fatalf("Expected %d queued turns but got %d", maxQueuedTurns, len(snake.turns))
+	}
+}
@@ -0,0 +1,390 @@
+package snake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"unicode/utf8"
+
+	"github.com/imega/snake-game/state"
+)
+
+// snapshotVersion is bumped whenever the snapshot format changes
+const snapshotVersion = 2
+
+// Snapshot is the full state of a game in progress. It can be edited by
+// hand to craft a situation, the replay of a restored game is only exact
+// when the snapshot was not edited.
+type Snapshot struct {
+	Version    int              `json:"version"`
+	Parameters state.Parameters `json:"parameters"`
+	Seed       int64            `json:"seed"`
+	Draws      uint64           `json:"draws"`
+	Ticks      int              `json:"ticks"`
+	Turns      []Turn           `json:"turns,omitempty"`
+	Speeds     []SpeedChange    `json:"speeds,omitempty"`
+	Speed      int              `json:"speed"`
+	Score      int              `json:"score"`
+	RivalScore int              `json:"rival_score,omitempty"`
+	Winner     int              `json:"winner,omitempty"`
+	Pace       int              `json:"pace,omitempty"`
+	IsOver     bool             `json:"is_over,omitempty"`
+	Death      string           `json:"death,omitempty"`
+	RivalDeath string           `json:"rival_death,omitempty"`
+	Elapsed    int              `json:"elapsed,omitempty"`
+	Reached    bool             `json:"reached,omitempty"`
+	Cleared    bool             `json:"cleared,omitempty"`
+	Tallies    []map[string]int `json:"tallies,omitempty"`
+	Fitness    []int            `json:"fitness,omitempty"`
+	Obstacles  []state.Coord    `json:"obstacles,omitempty"`
+	Snakes     []SnakeSnapshot  `json:"snakes"`
+	Foods      []FoodSnapshot   `json:"foods"`
+}
+
+// SnakeSnapshot is a snake with its body from the tail to the head and
+// the turns queued for the next ticks
+type SnakeSnapshot struct {
+	Body      []state.Coord  `json:"body"`
+	Direction string         `json:"direction"`
+	Length    int            `json:"length"`
+	Steps     int            `json:"steps"`
+	Combo     int            `json:"combo,omitempty"`
+	Queue     []string       `json:"queue,omitempty"`
+	Powers    map[string]int `json:"powers,omitempty"`
+}
+
+// FoodSnapshot is a food item on the board
+type FoodSnapshot struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Kind   string `json:"kind"`
+	Emoji  string `json:"emoji"`
+	Points int    `json:"points"`
+	TTL    int    `json:"ttl,omitempty"`
+}
+
+// Snapshot returns the full state of the current game
+func (e *Engine) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Version:    snapshotVersion,
+		Parameters: e.params,
+		Seed:       e.seed,
+		Draws:      e.src.draws,
+		Ticks:      e.ticks,
+		Turns:      append([]Turn(nil), e.turns...),
+		Speeds:     append([]SpeedChange(nil), e.speeds...),
+		Speed:      e.speed,
+		Score:      e.score,
+		RivalScore: e.rivalScore,
+		Winner:     e.winner,
+		Pace:       e.pace,
+		IsOver:     e.isOver,
+		Death:      e.deaths[0].String(),
+		RivalDeath: e.deaths[1].String(),
+		Elapsed:    e.elapsed,
+		Reached:    e.reached,
+		Cleared:    e.cleared,
+	}
+
+	for i := range e.arena.players() {
+		s.Tallies = append(s.Tallies, copyTally(e.tallies[i]))
+		s.Fitness = append(s.Fitness, e.fitness[i])
+	}
+
+	for _, o := range e.arena.obstacles {
+		s.Obstacles = append(s.Obstacles, state.Coord{X: o.x, Y: o.y})
+	}
+
+	for _, sn := range e.arena.players() {
+		s.Snakes = append(s.Snakes, snakeSnapshot(sn))
+	}
+
+	for _, f := range e.arena.foods {
+		s.Foods = append(s.Foods, FoodSnapshot{
+			X:      f.x,
+			Y:      f.y,
+			Kind:   f.kind.name,
+			Emoji:  string(f.emoji),
+			Points: f.points,
+			TTL:    f.ttl,
+		})
+	}
+
+	return s
+}
+
+func snakeSnapshot(s *snake) SnakeSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := SnakeSnapshot{
+		Direction: s.direction.String(),
+		Length:    s.length,
+		Steps:     s.steps,
+		Combo:     s.combo,
+	}
+
+	for _, c := range s.body {
+		ss.Body = append(ss.Body, state.Coord{X: c.x, Y: c.y})
+	}
+
+	for _, d := range s.turns {
+		ss.Queue = append(ss.Queue, d.String())
+	}
+
+	for _, p := range s.activePowers() {
+		if ss.Powers == nil {
+			ss.Powers = make(map[string]int)
+		}
+
+		ss.Powers[p.Name] = p.Ticks
+	}
+
+	return ss
+}
+
+// Restore replaces the current game with the snapshot
+func (e *Engine) Restore(s *Snapshot) error {
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+
+	p := arenaParameters(e.params, s.Parameters)
+	p.Obstacles = s.Obstacles
+
+	if err := validateParameters(p); err != nil {
+		return fmt.Errorf("invalid snapshot parameters, %s", err)
+	}
+
+	if s.Speed < 0 {
+		return fmt.Errorf("invalid snapshot speed %d", s.Speed)
+	}
+
+	want := 1
+	if p.TwoPlayers {
+		want = 2
+	}
+
+	if len(s.Snakes) != want {
+		return fmt.Errorf("snapshot has %d snakes, expected %d", len(s.Snakes), want)
+	}
+
+	var deaths [2]DeathCause
+	for i, d := range []string{s.Death, s.RivalDeath} {
+		c, err := parseDeathCause(d)
+		if err != nil {
+			return err
+		}
+
+		deaths[i] = c
+	}
+
+	snakes := make([]*snake, 0, len(s.Snakes))
+	for i, ss := range s.Snakes {
+		sn, err := restoreSnake(ss, p)
+		if err != nil {
+			return fmt.Errorf("invalid snake %d, %s", i+1, err)
+		}
+
+		snakes = append(snakes, sn)
+	}
+
+	a := initialArena(p, rand.New(rand.NewSource(s.Seed)))
+	a.snake = snakes[0]
+	a.rival = nil
+	if len(snakes) == 2 {
+		a.rival = snakes[1]
+	}
+
+	a.foods = a.foods[:0]
+	for i, fs := range s.Foods {
+		f, err := restoreFood(fs, p)
+		if err != nil {
+			return fmt.Errorf("invalid food %d, %s", i+1, err)
+		}
+
+		a.foods = append(a.foods, f)
+	}
+
+	a.track()
+
+	e.params = p
+	e.seed = s.Seed
+	e.src = restoreSource(s.Seed, s.Draws)
+	e.rnd = rand.New(e.src)
+	a.rnd = e.rnd
+	e.arena = a
+	e.ticks = s.Ticks
+	e.turns = append([]Turn(nil), s.Turns...)
+	e.speeds = append([]SpeedChange(nil), s.Speeds...)
+	e.speed = s.Speed
+	e.score = s.Score
+	e.rivalScore = s.RivalScore
+	e.winner = s.Winner
+	e.pace = s.Pace
+	e.isOver = s.IsOver
+	e.deaths = deaths
+	e.elapsed = s.Elapsed
+	e.reached = s.Reached
+	e.cleared = s.Cleared
+	e.tallies = [2]map[string]int{}
+	e.fitness = [2]int{}
+
+	for i := 0; i < len(s.Tallies) && i < 2; i++ {
+		e.tallies[i] = copyTally(s.Tallies[i])
+	}
+
+	for i := 0; i < len(s.Fitness) && i < 2; i++ {
+		e.fitness[i] = s.Fitness[i]
+	}
+	e.directions = e.directions[:0]
+
+	for _, sn := range a.players() {
+		e.directions = append(e.directions, sn.direction)
+	}
+
+	return nil
+}
+
+// arenaParameters takes the arena settings, the game mode and the speed
+// the game started at from src and keeps the play, terminal and training
+// settings of p
+func arenaParameters(p, src state.Parameters) state.Parameters {
+	p.Seed = src.Seed
+	p.Speed = src.Speed
+	p.Width = src.Width
+	p.Height = src.Height
+	p.SnakeLength = src.SnakeLength
+	p.StartX = src.StartX
+	p.StartY = src.StartY
+	p.StartDirection = src.StartDirection
+	p.Wrap = src.Wrap
+	p.Obstacles = src.Obstacles
+	p.FoodPoints = src.FoodPoints
+	p.FoodCount = src.FoodCount
+	p.FoodKinds = src.FoodKinds
+	p.FoodLifetime = src.FoodLifetime
+	p.TwoPlayers = src.TwoPlayers
+	p.Level = src.Level
+	p.Mode = src.Mode
+	p.TimeLimit = src.TimeLimit
+	p.Target = src.Target
+	p.Scoring = src.Scoring
+
+	return p
+}
+
+func restoreSnake(ss SnakeSnapshot, p state.Parameters) (*snake, error) {
+	if len(ss.Body) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	d, err := parseDirection(ss.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	body := make([]coord, 0, len(ss.Body))
+	for _, c := range ss.Body {
+		if !inside(p, c) {
+			return nil, fmt.Errorf("body at %d,%d is outside the arena", c.X, c.Y)
+		}
+
+		body = append(body, coord{x: c.X, y: c.Y})
+	}
+
+	s := newSnake(d, body)
+	s.steps = ss.Steps
+	s.combo = ss.Combo
+
+	if ss.Length >= len(body) {
+		s.length = ss.Length
+	}
+
+	for _, q := range ss.Queue {
+		qd, err := parseDirection(q)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queued turn, %s", err)
+		}
+
+		s.turns = append(s.turns, qd)
+	}
+
+	if err := restorePowers(s, ss.Powers); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func restoreFood(fs FoodSnapshot, p state.Parameters) (*food, error) {
+	k := findFoodKind(fs.Kind)
+	if k == nil {
+		return nil, fmt.Errorf("unknown food kind %q", fs.Kind)
+	}
+
+	if !inside(p, state.Coord{X: fs.X, Y: fs.Y}) {
+		return nil, fmt.Errorf("food at %d,%d is outside the arena", fs.X, fs.Y)
+	}
+
+	emoji := k.glyph
+	if r, n := utf8.DecodeRuneInString(fs.Emoji); n > 0 && r != utf8.RuneError {
+		emoji = r
+	}
+
+	return &food{
+		kind:   k,
+		emoji:  emoji,
+		ttl:    fs.TTL,
+		points: fs.Points,
+		x:      fs.X,
+		y:      fs.Y,
+	}, nil
+}
+
+func copyTally(t map[string]int) map[string]int {
+	if t == nil {
+		return nil
+	}
+
+	c := make(map[string]int, len(t))
+	for k, v := range t {
+		c[k] = v
+	}
+
+	return c
+}
+
+func inside(p state.Parameters, c state.Coord) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < p.Width && c.Y < p.Height
+}
+
+// Save writes the snapshot to the file
+func (s *Snapshot) Save(filename string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot, %s", err)
+	}
+
+	if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot, %s", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot reads a snapshot file
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot, %s", err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s, %s", filename, err)
+	}
+
+	return &s, nil
+}
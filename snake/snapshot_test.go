@@ -0,0 +1,115 @@
+package snake
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/imega/snake-game/state"
+)
+
+func TestSnapshotRestoresGameInProgress(t *testing.T) {
+	p := newDoubleParameters()
+	p.FoodKinds = nil
+	p.FoodCount = 3
+	p.Mode = ModeTimeAttack
+	p.Speed = 100
+	p.TimeLimit = 10
+
+	e1, err := NewEngine(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e1.Reset(11)
+	e1.Step(MoveUp)
+	e1.Step(NOOP)
+	e1.SetSpeed(80)
+	e1.arena.snake.turn(RIGHT)
+	e1.arena.snake.give(ghost, 12)
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "snapshot.json")
+	if err := e1.Snapshot().Save(name); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSnapshot(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e2 := newDoubleEngine(t)
+	if err := e2.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(e1.State(), e2.State()) {
+		t.Fatalf("Expected restored state %+v but got %+v", e1.State(), e2.State())
+	}
+
+	for i := 0; i < 30; i++ {
+		e1.arena.findFood = func(*arena, coord) int { return i % 3 }
+		e2.arena.findFood = e1.arena.findFood
+
+		s1, _, _ := e1.Step(NOOP)
+		s2, _, _ := e2.Step(NOOP)
+
+		if !reflect.DeepEqual(s1, s2) {
+			t.Fatalf("Expected games to stay the same after tick %d", i)
+		}
+	}
+
+	if e1.src.draws != e2.src.draws {
+		t.Fatalf("Expected the same random draws but got %d and %d", e1.src.draws, e2.src.draws)
+	}
+
+	if !reflect.DeepEqual(e1.Replay(), e2.Replay()) {
+		t.Fatalf("Expected the replay to keep the speed of the game but got %+v", e2.Replay())
+	}
+}
+
+func TestRestoreRejectsInvalidSnapshots(t *testing.T) {
+	cases := map[string]func(*Snapshot){
+		"version":        func(s *Snapshot) { s.Version = 0 },
+		"no snake":       func(s *Snapshot) { s.Snakes = nil },
+		"empty body":     func(s *Snapshot) { s.Snakes[0].Body = nil },
+		"body outside":   func(s *Snapshot) { s.Snakes[0].Body[0] = state.Coord{X: -1, Y: 0} },
+		"bad direction":  func(s *Snapshot) { s.Snakes[0].Direction = "north" },
+		"unknown food":   func(s *Snapshot) { s.Foods[0].Kind = "brick" },
+		"food outside":   func(s *Snapshot) { s.Foods[0].Y = 20 },
+		"bad parameters": func(s *Snapshot) { s.Parameters.Width = 0 },
+		"unknown death":  func(s *Snapshot) { s.Death = "boredom" },
+		"unknown power":  func(s *Snapshot) { s.Snakes[0].Powers = map[string]int{"flight": 3} },
+	}
+
+	for name, f := range cases {
+		e := newDoubleEngine(t)
+		s := e.Snapshot()
+		f(s)
+
+		if err := e.Restore(s); err == nil {
+			t.Fatalf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestRestoreSourceContinuesSequence(t *testing.T) {
+	s1 := newCountingSource(5)
+	for i := 0; i < 7; i++ {
+		s1.Int63()
+	}
+
+	s2 := restoreSource(5, s1.draws)
+
+	if s1.Int63() != s2.Int63() {
+		t.Fatal("Expected restored source to continue the sequence")
+	}
+}
@@ -0,0 +1,40 @@
+package snake
+
+import "math/rand"
+
+// countingSource is a random source that remembers its seed and how many
+// numbers it has handed out, so that its state can be saved and restored
+type countingSource struct {
+	src   rand.Source
+	seed  int64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{
+		src:  rand.NewSource(seed),
+		seed: seed,
+	}
+}
+
+// restoreSource returns a source seeded with the seed that has already
+// handed out the given number of values
+func restoreSource(seed int64, draws uint64) *countingSource {
+	s := newCountingSource(seed)
+	for s.draws < draws {
+		s.Int63()
+	}
+
+	return s
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
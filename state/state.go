@@ -1,27 +1,62 @@
 package state
 
 type SnakeGame struct {
-	Arena  Arena
-	Snake  Snake
-	Food   Coord
-	IsOver bool
-	Score  int
+	Arena           Arena
+	Snake           Snake
+	Rival           *Snake
+	Foods           []Food
+	IsOver          bool
+	Score           int
+	RivalScore      int
+	Winner          int
+	DeathCause      string
+	RivalDeathCause string
+	Mode            string
+	TimeLeft        int
+	TargetReached   bool
+	BoardCleared    bool
+	Fitness         int
+	ScoreParts      []ScorePart
+}
+
+type ScorePart struct {
+	Rule   string
+	Points int
 }
 
 type Arena struct {
-	Width  int
-	Height int
+	Width     int
+	Height    int
+	Wrap      bool
+	Obstacles []Coord
 }
 
 type Snake struct {
-	Head  Coord
-	Body  []Coord
-	Steps int
+	Head   Coord
+	Body   []Coord
+	Steps  int
+	Powers []PowerUp
+}
+
+type PowerUp struct {
+	Name  string
+	Ticks int
+}
+
+type Food struct {
+	X      int
+	Y      int
+	Points int
+	Kind   string
+	Growth int
+	Effect string
+	Power  string
+	TTL    int
 }
 
 type Coord struct {
-	X int
-	Y int
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 type Stat struct {
@@ -33,15 +68,41 @@ type Stat struct {
 }
 
 type Parameters struct {
-	Speed          int
-	MaxInstance    int
-	MutationRate   float64
-	MutationRange  float64
-	MaxSnakeSteps  int
-	MinScoreEpoch  int
-	PrefixFilename string
-	Silent         bool
-	Human          bool
-	BrainFilename  string
-	CreateBrain    bool
+	Speed          int      `json:"speed"`
+	MaxInstance    int      `json:"max_instance"`
+	MutationRate   float64  `json:"mutation_rate"`
+	MutationRange  float64  `json:"mutation_range"`
+	MaxSnakeSteps  int      `json:"max_snake_steps"`
+	MaxTicks       int      `json:"max_ticks"`
+	Mode           string   `json:"mode,omitempty"`
+	TimeLimit      int      `json:"time_limit,omitempty"`
+	Target         int      `json:"target,omitempty"`
+	Scoring        []string `json:"scoring,omitempty"`
+	MinScoreEpoch  int      `json:"min_score_epoch"`
+	PrefixFilename string   `json:"prefix,omitempty"`
+	Silent         bool     `json:"silent"`
+	Human          bool     `json:"-"`
+	BrainFilename  string   `json:"-"`
+	Seed           int64    `json:"seed"`
+	Width          int      `json:"width"`
+	Height         int      `json:"height"`
+	SnakeLength    int      `json:"length"`
+	StartX         int      `json:"start_x"`
+	StartY         int      `json:"start_y"`
+	StartDirection string   `json:"direction"`
+	Wrap           bool     `json:"wrap"`
+	Obstacles      []Coord  `json:"obstacles,omitempty"`
+	FoodPoints     int      `json:"food_points"`
+	FoodCount      int      `json:"food_count"`
+	FoodKinds      []string `json:"food_kinds,omitempty"`
+	FoodLifetime   int      `json:"food_lifetime"`
+	TwoPlayers     bool     `json:"two_players"`
+	Watch          bool     `json:"-"`
+	Versus         bool     `json:"-"`
+	Level          string   `json:"level,omitempty"`
+	Keys           string   `json:"keys,omitempty"`
+	Record         string   `json:"record,omitempty"`
+	Snapshot       string   `json:"snapshot,omitempty"`
+	Player         string   `json:"player,omitempty"`
+	Scores         string   `json:"scores,omitempty"`
 }